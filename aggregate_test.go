@@ -0,0 +1,135 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+type dropItemCfg struct {
+	ItemId uint64
+	Weight uint32
+}
+
+type dropCfg struct {
+	Id    uint64
+	Items []dropItemCfg
+	Stats map[string]int
+}
+
+type dropCfgChecker struct {
+	Id    ValueRangerChecker
+	Items ValueRangerChecker
+	Stats ValueRangerChecker
+}
+
+type dropItemCfgChecker struct {
+	ItemId ValueRangerChecker
+	Weight ValueRangerChecker
+}
+
+func TestAggregate(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	newChecker := func() ValueRangerChecker {
+		items := valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StructValueRangerChecker(dropItemCfgChecker{
+			ItemId: valueRangeChecker.RefValueRangerChecker("itemCfg.Id"),
+			Weight: valueRangeChecker.IntValueRangerChecker(""),
+		}))
+		stats := valueRangeChecker.MapValueRangerChecker(valueRangeChecker.StringValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker(""))
+		return valueRangeChecker.WithAggregates(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StructValueRangerChecker(dropCfgChecker{
+			Id: valueRangeChecker.IntValueRangerChecker(""),
+			Items: valueRangeChecker.WithAggregates(items,
+				valueRangeChecker.SumAggregate("Weight", "[10000,10000]"),
+				valueRangeChecker.CountAggregate("Weight == 0", "[0,0]"),
+			),
+			Stats: valueRangeChecker.WithAggregates(stats,
+				valueRangeChecker.SumAggregate("", "[0,100]"),
+				valueRangeChecker.MaxAggregate("", "[0,60]"),
+				valueRangeChecker.MinAggregate("", "[1,-]"),
+			),
+		})), valueRangeChecker.CountFuncAggregate("has stats", func(row any) bool {
+			return len(row.(dropCfg).Stats) > 0
+		}, "[1,-]"))
+	}
+
+	good := []dropCfg{
+		{Id: 1, Items: []dropItemCfg{{ItemId: 1001, Weight: 6000}, {ItemId: 1002, Weight: 4000}}, Stats: map[string]int{"hp": 60, "mp": 40}},
+		{Id: 2, Items: []dropItemCfg{{ItemId: 1001, Weight: 10000}}}, // 空的 map 不检测 min、max
+	}
+	bad := []dropCfg{
+		{Id: 1, Items: []dropItemCfg{{ItemId: 1001, Weight: 6000}, {ItemId: 1002, Weight: 3000}, {ItemId: 1002}}, Stats: map[string]int{"hp": 70, "mp": 40}},
+		{Id: 2, Items: []dropItemCfg{{ItemId: 1001, Weight: 10000}}, Stats: map[string]int{"hp": 0}},
+	}
+	checkBothPaths(t, valueRangeChecker, "drop", newChecker, []dropCfg{}, good)
+	checkBothPaths(t, valueRangeChecker, "drop", nil, nil, bad,
+		"[0].Items: sum(Weight) 9000 not match [10000,10000]",
+		"[0].Items: count(Weight == 0) 1 not match [0,0]",
+		"[0].Stats: sum 110 not match [0,100]",
+		"[0].Stats: max 70 not match [0,60]",
+		"[1].Stats: min 0 not match [1,-]",
+	)
+	// 整张表上的汇总
+	checkBothPaths(t, valueRangeChecker, "drop", nil, nil, good[1:], ": count(has stats) 0 not match [1,-]")
+
+	// 值不是整数
+	checker := valueRangeChecker.WithAggregates(valueRangeChecker.ListValueRangerChecker(anyValueChecker{}), valueRangeChecker.SumAggregate("", "[0,-]"))
+	valueRangeChecker.RegChecker("strs", checker)
+	if violations := valueRangeChecker.CheckWithReport("strs", []any{1, "2"}); len(violations) != 1 || violations[0].String() != "strs: sum error: at [1]: value 2 is not an int" {
+		t.Errorf("strs violations: %v", violations)
+	}
+
+	for _, fn := range []func(){
+		func() {
+			valueRangeChecker.WithAggregates(valueRangeChecker.IntValueRangerChecker(""), valueRangeChecker.SumAggregate("", "[0,-]"))
+		},
+		func() { valueRangeChecker.SumAggregate("Weight", "") },
+		func() { valueRangeChecker.CountAggregate("Weight ==", "[0,0]") },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestAggregateRuleFile(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	valueRangeChecker.LoadOneCfg("dropCfg", []dropCfg{
+		{Id: 1, Items: []dropItemCfg{{ItemId: 1001, Weight: 6000}, {ItemId: 1002, Weight: 4000}}},
+		{Id: 2, Items: []dropItemCfg{{ItemId: 1001, Weight: 5000}}},
+	})
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"dropCfg": {"list": {"struct": {
+			"Id": "int",
+			"Items": {"list": {"struct": {"ItemId": "ref=itemCfg.Id", "Weight": "int"}}, "sum": {"Weight": "[10000,10000]"}, "max": {"Weight": "(0,10000]"}}
+		}}, "count": "[1,100]"}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	violations := report.Tables[0].Violations
+	if len(violations) != 1 || violations[0].String() != "dropCfg[1].Items: sum(Weight) 5000 not match [10000,10000]" {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"list": "int", "sum": "[5,1]"}}}`, `rules.json:1:41: sum IntRange max value less than min value`},
+		{`{"tables": {"a": {"list": "int", "min": {"Weight": 1}}}}`, `rules.json:1:42: min must be field name => range string`},
+		{`{"tables": {"a": {"list": "int", "max": true}}}`, `rules.json:1:41: max must be a range string or an object of field => range, is: bool`},
+		{`{"tables": {"a": {"list": "int", "count": {"Weight ==": "[0,0]"}}}}`, `rules.json:1:44: count "Weight ==" illegal`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "sum": "[0,1]"}}}`, `rules.json:1:44: sum can only be used with list, map, is: struct`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
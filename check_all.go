@@ -0,0 +1,122 @@
+package valuerange
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+)
+
+// 一条检测不通过的记录
+type Violation = basetyperange.Violation
+
+// 一张配置表的检测结果
+type TableReport struct {
+	Key        string
+	Violations []Violation
+	Warnings   []Violation // 不影响是否通过的提醒，如严格模式为 StrictWarn 时没有规则的字段
+}
+
+func (tr TableReport) Pass() bool {
+	return len(tr.Violations) == 0
+}
+
+// CheckAll 的汇总报告
+type CheckAllReport struct {
+	Tables    []TableReport // 既有数据又有 checker 的表的检测结果，按 key 排序
+	NoChecker []string      // 加载了数据，但是没有注册 checker 的表，这些表的数据没有被检测
+	NoData    []string      // 注册了 checker，但是没有加载数据的 key，协议之类的 checker 在这里是正常的
+}
+
+// 所有检测过的表都通过了
+func (r *CheckAllReport) Pass() bool {
+	for _, table := range r.Tables {
+		if !table.Pass() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *CheckAllReport) String() string {
+	var sb strings.Builder
+	for _, table := range r.Tables {
+		if table.Pass() {
+			fmt.Fprintf(&sb, "%s: PASS\n", table.Key)
+		} else {
+			fmt.Fprintf(&sb, "%s: FAIL (%d)\n", table.Key, len(table.Violations))
+		}
+		for _, v := range table.Violations {
+			fmt.Fprintf(&sb, "  %s\n", v.String())
+		}
+		for _, v := range table.Warnings {
+			fmt.Fprintf(&sb, "  warning: %s\n", v.String())
+		}
+	}
+	if len(r.NoChecker) > 0 {
+		fmt.Fprintf(&sb, "no checker: %s\n", strings.Join(r.NoChecker, ", "))
+	}
+	if len(r.NoData) > 0 {
+		fmt.Fprintf(&sb, "no data: %s\n", strings.Join(r.NoData, ", "))
+	}
+	return sb.String()
+}
+
+// 用 key 对应的 checker 去检测，返回所有不通过的记录，全部通过时返回空
+func (vr *ValueRange) CheckWithReport(key string, value any) []Violation {
+	return vr.checkWithReport(key, value, false).Violations
+}
+
+// loaded 表示检测的是加载进来的那份数据，这时才能用数据来源定位、主键表示行
+func (vr *ValueRange) checkWithReport(key string, value any, loaded bool) *basetyperange.Report {
+	report := basetyperange.NewReport(key)
+	if loaded {
+		report.Locator = vr.locators[key]
+		if _, ok := vr.refStore.PrimaryKey(key); ok {
+			report.RowName = func(seg basetyperange.PathSeg) (string, bool) {
+				return vr.refStore.RowName(key, seg)
+			}
+		}
+	}
+	checker, ok := vr.checkerStore[key]
+	if !ok {
+		report.Failf("check rule not exit")
+		return report
+	}
+	basetyperange.CheckWithReport(checker, value, report)
+	return report
+}
+
+// 用注册的 checker 检测所有通过 LoadOneCfg 加载过的配置表
+// 数据和 checker 通过同一个 key 对应起来，对应不上的分别记到 NoChecker 和 NoData 中
+func (vr *ValueRange) CheckAll() *CheckAllReport {
+	result := &CheckAllReport{}
+
+	loaded := make(map[string]struct{})
+	if vr.refStore != nil {
+		for _, key := range vr.refStore.OriDataKeys() {
+			loaded[key] = struct{}{}
+			if _, ok := vr.checkerStore[key]; !ok {
+				result.NoChecker = append(result.NoChecker, key)
+				continue
+			}
+			data, _ := vr.refStore.OriData(key)
+			report := vr.checkWithReport(key, data, true)
+			result.Tables = append(result.Tables, TableReport{
+				Key:        key,
+				Violations: report.Violations,
+				Warnings:   report.Warnings,
+			})
+		}
+	}
+
+	for key := range vr.checkerStore {
+		if _, ok := loaded[key]; !ok {
+			result.NoData = append(result.NoData, key)
+		}
+	}
+	sort.Strings(result.NoData)
+
+	return result
+}
@@ -0,0 +1,98 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// 创建一个加载好 hero 相关配置和检测规则的检测对象，给各个 test 复用
+func newHeroValueRange(t testing.TB) *ValueRange {
+	valueRangeChecker := ValueRangeChecker()
+	if !valueRangeChecker.LoadOneCfg(heroCfgKey, heroCfgList) {
+		t.Fatalf("load heroCfg data failed")
+	}
+	if !valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList) {
+		t.Fatalf("load heroSkinCfg data failed")
+	}
+	valueRangeChecker.LoadOneEnumCfg(enumHeroCfgQualityKey, map[uint64]struct{}{
+		uint64(heroCfgQuality_1): {}, uint64(heroCfgQuality_2): {}, uint64(heroCfgQuality_3): {},
+		uint64(heroCfgQuality_4): {}, uint64(heroCfgQuality_5): {},
+	})
+	valueRangeChecker.LoadOneEnumCfg(enumHeroCfgAttr, map[uint64]struct{}{
+		uint64(heroCfgAttr_hp): {}, uint64(heroCfgAttr_mp): {},
+	})
+
+	valueRangeChecker.RegChecker(heroCfgKey, newHeroCfgListChecker(valueRangeChecker))
+	valueRangeChecker.RegChecker(heroSkinCfgKey, valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StructValueRangerChecker(heroSkinCfgChecker{
+		Id:   valueRangeChecker.IntValueRangerChecker(""),
+		Desc: valueRangeChecker.StringValueRangerChecker(""),
+	})))
+	return valueRangeChecker
+}
+
+// heroCfg 表的检测规则，和 TestCfgCheck 中注册的一样
+func newHeroCfgListChecker(valueRangeChecker *ValueRange) ValueRangerChecker {
+	return valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StructValueRangerChecker(heroCfgChecker{
+		Id:      valueRangeChecker.IntValueRangerChecker(""),
+		Desc:    valueRangeChecker.StringValueRangerChecker(""),
+		Quality: valueRangeChecker.EnumValueRangerChecker(enumHeroCfgQualityKey),
+		Open:    valueRangeChecker.BoolValueRangerChecker(""),
+		Tag: valueRangeChecker.StructValueRangerChecker(heroTagCfgChecker{
+			Free: valueRangeChecker.BoolValueRangerChecker(""),
+		}),
+		Skins: valueRangeChecker.ListValueRangerChecker(valueRangeChecker.RefValueRangerChecker(heroSkinCfgKey + ".Id")),
+		Attrs: valueRangeChecker.MapValueRangerChecker(valueRangeChecker.EnumValueRangerChecker(enumHeroCfgAttr), valueRangeChecker.IntValueRangerChecker("(0,-)")),
+	}))
+}
+
+func TestCheckAll(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+
+	report := valueRangeChecker.CheckAll()
+	if !report.Pass() || len(report.Tables) != 2 {
+		t.Fatalf("check all failed:\n%s", report.String())
+	}
+
+	// 一张有问题的表，一张没有 checker 的表，一个没有数据的 checker
+	const badHeroCfgKey = "badHeroCfg"
+	badHeroCfgList := []heroCfg{
+		heroCfgList[0],
+		{Id: 61404, Desc: "bad", Quality: 7, Skins: []uint64{6140101, 9}, Attrs: map[uint32]uint32{1: 0}},
+	}
+	valueRangeChecker.LoadOneCfg(badHeroCfgKey, badHeroCfgList)
+	valueRangeChecker.RegChecker(badHeroCfgKey, newHeroCfgListChecker(valueRangeChecker))
+	valueRangeChecker.LoadOneCfg("npcCfg", []heroSkinCfg{{Id: 1}})
+	valueRangeChecker.RegChecker("heroProto", valueRangeChecker.StringValueRangerChecker(""))
+
+	report = valueRangeChecker.CheckAll()
+	if report.Pass() {
+		t.Fatalf("check all should fail:\n%s", report.String())
+	}
+	if len(report.NoChecker) != 1 || report.NoChecker[0] != "npcCfg" {
+		t.Errorf("no checker tables: %v", report.NoChecker)
+	}
+	if len(report.NoData) != 1 || report.NoData[0] != "heroProto" {
+		t.Errorf("no data checkers: %v", report.NoData)
+	}
+
+	var badTable TableReport
+	for _, table := range report.Tables {
+		if table.Key == badHeroCfgKey {
+			badTable = table
+		} else if !table.Pass() {
+			t.Errorf("table %s should pass: %v", table.Key, table.Violations)
+		}
+	}
+	wantPaths := []string{"badHeroCfg[1].Quality", "badHeroCfg[1].Skins[1]", "badHeroCfg[1].Attrs[1]"}
+	if len(badTable.Violations) != len(wantPaths) {
+		t.Fatalf("bad table violations: %v", badTable.Violations)
+	}
+	for i, want := range wantPaths {
+		if badTable.Violations[i].Path != want {
+			t.Errorf("violation %d path: %s, want: %s", i, badTable.Violations[i].Path, want)
+		}
+	}
+	if !strings.Contains(report.String(), "badHeroCfg: FAIL (3)") {
+		t.Errorf("report string:\n%s", report.String())
+	}
+}
@@ -0,0 +1,137 @@
+// valuerange 命令行，策划提交配置表之前可以自己跑一下检测
+//
+//	valuerange -rules rules.json -data ./cfg
+//
+// -data 目录下的 .csv、.json 文件就是配置表，文件名（不含扩展名）是表的 key，和规则文件 tables 中的 key 对应
+// 规则文件的格式见 internal/rule-schema，csv 的格式见 internal/csv-table
+// 规则中的表没有数据文件时算不通过，加 -allow-missing 时不算
+// 全部通过时退出码为 0，有不通过的、数据加载失败、缺数据文件为 1，参数、规则文件、数据目录有问题为 2
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	valuerange "github.com/chenjinjie/value-range"
+	ruleschema "github.com/chenjinjie/value-range/internal/rule-schema"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("valuerange", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	rulesPath := fs.String("rules", "", "规则文件，必填")
+	dataDir := fs.String("data", "", "配置表所在的目录，必填")
+	verbose := fs.Bool("v", false, "输出检测过程中的调试信息")
+	listSep := fs.String("list-sep", ";", "csv 单元格中列表元素的分隔符")
+	kvSep := fs.String("kv-sep", ":", "csv 单元格中 map 的 key 和 value 的分隔符")
+	allowMissing := fs.Bool("allow-missing", false, "规则中的表没有数据文件时也算通过，默认不通过，免得文件改名、删掉之后规则悄悄失效")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *rulesPath == "" || *dataDir == "" {
+		fs.Usage()
+		return 2
+	}
+
+	// 检测过程中的调试信息默认不要，只输出报告
+	if *verbose {
+		valuerange.SetDebugOutput(stdout)
+	} else {
+		valuerange.SetDebugOutput(io.Discard)
+	}
+
+	rulesData, err := os.ReadFile(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "read rules failed: %v\n", err)
+		return 2
+	}
+	schema, err := ruleschema.Parse(*rulesPath, rulesData)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+
+	files, err := dataFiles(*dataDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "read data dir failed: %v\n", err)
+		return 2
+	}
+
+	vr := valuerange.ValueRangeChecker()
+	tb := newTypeBuilder(schema)
+	loadFailed := false
+	for _, file := range files {
+		key := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if _, ok := tb.tables[key]; !ok {
+			fmt.Fprintf(stderr, "skip %s: no rule for table %s\n", file, key)
+			continue
+		}
+		if err := loadTable(vr, tb, key, file, valuerange.CSVOptions{ListSep: *listSep, KVSep: *kvSep}); err != nil {
+			fmt.Fprintf(stdout, "%s: LOAD FAIL\n  %v\n", key, err)
+			loadFailed = true
+		}
+	}
+
+	// 规则中的 ref 需要引用的数据已经加载好了，所以在数据之后加载
+	if err := vr.LoadRules(*rulesPath, rulesData); err != nil {
+		if loadFailed { // 多半是被引用的表没有加载成功导致的，先去修数据
+			fmt.Fprintf(stdout, "rules not loaded because of data load failure: %v\nFAIL\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 2
+	}
+
+	report := vr.CheckAll()
+	fmt.Fprint(stdout, report.String())
+	if !report.Pass() || loadFailed || (len(report.NoData) > 0 && !*allowMissing) {
+		fmt.Fprintln(stdout, "FAIL")
+		return 1
+	}
+	fmt.Fprintln(stdout, "PASS")
+	return 0
+}
+
+// 目录下所有的 .csv、.json 文件，按文件名排序
+func dataFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".csv" && ext != ".json") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// 按规则对应的类型解析一张表，加载到 vr 中
+func loadTable(vr *valuerange.ValueRange, tb *typeBuilder, key string, file string, csvOpts valuerange.CSVOptions) error {
+	tableType, err := tb.tableType(key)
+	if err != nil {
+		return err
+	}
+
+	sample := reflect.Zero(tableType).Interface()
+	if strings.ToLower(filepath.Ext(file)) == ".csv" {
+		_, err := vr.LoadOneCSVFile(key, file, sample, csvOpts)
+		return err
+	}
+	_, err = vr.LoadOneJSONFile(key, file, sample)
+	return err
+}
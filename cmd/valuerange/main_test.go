@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 和仓库根目录的规则文件是同一份
+const rulesFile = "../../testdata/rules.json"
+
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	code, out, errOut := runCLI("-rules", rulesFile, "-data", "testdata/good")
+	if code != 0 || !strings.HasSuffix(out, "PASS\n") {
+		t.Errorf("good data exit: %d\nstdout:\n%s\nstderr:\n%s", code, out, errOut)
+	}
+
+	code, out, errOut = runCLI("-rules", rulesFile, "-data", "testdata/bad")
+	wants := []string{
+		"heroCfg: FAIL (3)",
+		"testdata/bad/heroCfg.csv:3:C: heroCfg[1].Quality: value 7 (int64) not match enum(heroCfgQuality)",
+		"testdata/bad/heroCfg.csv:3:F: heroCfg[1].Skins[1]: value 9 (int64) not match ref(heroSkinCfg.Id)",
+		"testdata/bad/heroCfg.csv:3:G: heroCfg[1].Attrs[3]: value 3 (int64) not match enum(heroCfgAttr)",
+		"heroSkinCfg: PASS",
+	}
+	if code != 1 || !strings.HasSuffix(out, "FAIL\n") {
+		t.Errorf("bad data exit: %d\nstdout:\n%s", code, out)
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("bad data report missing: %s\n%s", want, out)
+		}
+	}
+	if !strings.Contains(errOut, "no rule for table itemCfg") {
+		t.Errorf("bad data stderr: %s", errOut)
+	}
+
+	code, out, _ = runCLI("-rules", rulesFile, "-data", "testdata/badjson")
+	for _, want := range []string{
+		"testdata/badjson/heroCfg.json:3:42: heroCfg[1].Quality: value 7 (int64) not match enum(heroCfgQuality)",
+		"testdata/badjson/heroCfg.json:3:102: heroCfg[1].Skins[1]: value 9 (int64) not match ref(heroSkinCfg.Id)",
+		"testdata/badjson/heroCfg.json:3:131: heroCfg[1].Attrs[3]: value 3 (int64) not match enum(heroCfgAttr)",
+	} {
+		if code != 1 || !strings.Contains(out, want) {
+			t.Errorf("bad json exit: %d, report missing: %s\n%s", code, want, out)
+		}
+	}
+
+	code, out, _ = runCLI("-rules", rulesFile, "-data", "testdata/broken")
+	if code != 1 || !strings.Contains(out, `testdata/broken/heroSkinCfg.csv:3:A: Id: parse int64 "61x" failed`) {
+		t.Errorf("broken data exit: %d\nstdout:\n%s", code, out)
+	}
+
+	// 规则中的表没有数据文件
+	code, out, _ = runCLI("-rules", rulesFile, "-data", "testdata/missing")
+	if code != 1 || !strings.Contains(out, "no data: heroCfg") || !strings.HasSuffix(out, "FAIL\n") {
+		t.Errorf("missing data exit: %d\nstdout:\n%s", code, out)
+	}
+	code, out, _ = runCLI("-allow-missing", "-rules", rulesFile, "-data", "testdata/missing")
+	if code != 0 || !strings.HasSuffix(out, "PASS\n") {
+		t.Errorf("allow missing data exit: %d\nstdout:\n%s", code, out)
+	}
+
+	code, _, errOut = runCLI("-rules", rulesFile, "-data", "testdata/no_such_dir")
+	if code != 2 || !strings.Contains(errOut, "read data dir failed") {
+		t.Errorf("no data dir exit: %d, stderr: %s", code, errOut)
+	}
+
+	code, _, errOut = runCLI("-rules", "testdata/no_such_rules.json", "-data", "testdata/good")
+	if code != 2 || !strings.Contains(errOut, "read rules failed") {
+		t.Errorf("no rules exit: %d, stderr: %s", code, errOut)
+	}
+}
+
+// 调试信息写到传进来的 stdout 中，默认不输出
+func TestRunVerbose(t *testing.T) {
+	_, out, _ := runCLI("-rules", rulesFile, "-data", "testdata/bad")
+	_, verboseOut, _ := runCLI("-v", "-rules", rulesFile, "-data", "testdata/bad")
+	if !strings.HasSuffix(verboseOut, out) || len(verboseOut) == len(out) {
+		t.Errorf("verbose stdout should be debug info followed by report:\n%s\nreport:\n%s", verboseOut, out)
+	}
+}
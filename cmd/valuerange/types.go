@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	ruleschema "github.com/chenjinjie/value-range/internal/rule-schema"
+)
+
+// 命令行没有配置表的 Go 类型，根据规则文件中的规则，用反射动态创建对应的类型，数据就解析到这个类型上
+// 这样 RefStore、StructRange 等都和在 Go 代码中使用的时候一样
+//  1. int、enum => int64
+//  2. string => string，bool => bool
+//  3. ref => 被引用的字段的类型
+//  4. list => slice，map => map，struct => 字段名就是规则中的字段名
+//
+// 数字统一用 int64，这样被引用的表和引用的值的类型是一致的，ref 才能对上
+
+type typeBuilder struct {
+	tables   map[string]*ruleschema.Rule // 表 key => 表的规则
+	resolved map[*ruleschema.Rule]reflect.Type
+	visiting map[*ruleschema.Rule]struct{} // 正在解析的 ref，防止 ref 循环
+}
+
+func newTypeBuilder(schema *ruleschema.Schema) *typeBuilder {
+	tb := &typeBuilder{
+		tables:   make(map[string]*ruleschema.Rule),
+		resolved: make(map[*ruleschema.Rule]reflect.Type),
+		visiting: make(map[*ruleschema.Rule]struct{}),
+	}
+	for _, table := range schema.Tables {
+		tb.tables[table.Key] = table.Rule
+	}
+	return tb
+}
+
+func (tb *typeBuilder) tableType(key string) (reflect.Type, error) {
+	rule, ok := tb.tables[key]
+	if !ok {
+		return nil, fmt.Errorf("no rule for table %s", key)
+	}
+	return tb.build(rule)
+}
+
+func (tb *typeBuilder) build(rule *ruleschema.Rule) (reflect.Type, error) {
+	if t, ok := tb.resolved[rule]; ok {
+		return t, nil
+	}
+
+	var t reflect.Type
+	switch rule.Kind {
+	case "int", "enum":
+		t = reflect.TypeOf(int64(0))
+	case "string":
+		t = reflect.TypeOf("")
+	case "bool":
+		t = reflect.TypeOf(false)
+	case "ref":
+		refType, err := tb.refType(rule)
+		if err != nil {
+			return nil, err
+		}
+		t = refType
+	case "list":
+		elemType, err := tb.build(rule.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = reflect.SliceOf(elemType)
+	case "map":
+		keyType, err := tb.build(rule.Key)
+		if err != nil {
+			return nil, err
+		}
+		elemType, err := tb.build(rule.Elem)
+		if err != nil {
+			return nil, err
+		}
+		t = reflect.MapOf(keyType, elemType)
+	case "struct":
+		fields := make([]reflect.StructField, 0, len(rule.Fields))
+		for _, field := range rule.Fields {
+			if !isExportedName(field.Name) {
+				return nil, fmt.Errorf("%s: field name %q must start with an upper case letter", field.Rule.Pos, field.Name)
+			}
+			fieldType, err := tb.build(field.Rule)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, reflect.StructField{
+				Name: field.Name,
+				Type: fieldType,
+				Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s"`, field.Name)),
+			})
+		}
+		t = reflect.StructOf(fields)
+	default:
+		return nil, fmt.Errorf("%s: unknown rule %q", rule.Pos, rule.Kind)
+	}
+
+	tb.resolved[rule] = t
+	return t, nil
+}
+
+// ref 的类型是被引用的那个字段的类型
+func (tb *typeBuilder) refType(rule *ruleschema.Rule) (reflect.Type, error) {
+	if _, ok := tb.visiting[rule]; ok {
+		return nil, fmt.Errorf("%s: ref loop: %s", rule.Pos, rule.Arg)
+	}
+	tb.visiting[rule] = struct{}{}
+	defer delete(tb.visiting, rule)
+
+	tableKey, fieldName, ok := strings.Cut(rule.Arg, ".")
+	if !ok {
+		return nil, fmt.Errorf("%s: ref %q must be table.field", rule.Pos, rule.Arg)
+	}
+	tableRule, ok := tb.tables[tableKey]
+	if !ok {
+		return nil, fmt.Errorf("%s: ref table %q has no rule", rule.Pos, tableKey)
+	}
+	rowRule := tableRule
+	if rowRule.Kind == "list" || rowRule.Kind == "map" { // 和 RefStore 一样，list、map 的表取元素的字段
+		rowRule = rowRule.Elem
+	}
+	if rowRule.Kind != "struct" {
+		return nil, fmt.Errorf("%s: ref table %q rows are not struct", rule.Pos, tableKey)
+	}
+	for _, field := range rowRule.Fields {
+		if field.Name == fieldName {
+			if !ruleschema.IsLeafKind(field.Rule.Kind) {
+				return nil, fmt.Errorf("%s: ref field %q is not int or string", rule.Pos, rule.Arg)
+			}
+			return tb.build(field.Rule)
+		}
+	}
+	return nil, fmt.Errorf("%s: ref table %q has no field %q", rule.Pos, tableKey, fieldName)
+}
+
+// 能作为导出字段名的标识符
+func isExportedName(name string) bool {
+	for i, c := range name {
+		if i == 0 && !unicode.IsUpper(c) {
+			return false
+		}
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const valueRangePkgPath = "github.com/chenjinjie/value-range"
+
+// 读取 dir 包中的类型，生成 checker 骨架的源码
+func generate(dir string, typeNames []string, withTests bool) ([]byte, error) {
+	pkg, err := loadPackage(dir, withTests)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, done: make(map[string]struct{})}
+	if pkg.Path() != valueRangePkgPath {
+		g.qualifier = "valuerange."
+	}
+	for _, name := range typeNames {
+		if err := g.genType(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by vrgen -type %s; DO NOT EDIT.\n\n", strings.Join(typeNames, ","))
+	fmt.Fprintf(&out, "package %s\n\n", pkg.Name())
+	if g.qualifier != "" {
+		fmt.Fprintf(&out, "import valuerange %q\n\n", valueRangePkgPath)
+	}
+	out.Write(g.body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code failed: %v\n%s", err, out.String())
+	}
+	return src, nil
+}
+
+// 用 go/types 加载包，其他包的类型加载失败也不影响，那些字段会被标记为不支持
+func loadPackage(dir string, withTests bool) (*types.Package, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("import dir %s failed: %v", dir, err)
+	}
+	fileNames := append([]string{}, buildPkg.GoFiles...)
+	if withTests {
+		fileNames = append(fileNames, buildPkg.TestGoFiles...)
+	}
+
+	fset := token.NewFileSet()
+	files := make([]*ast.File, 0, len(fileNames))
+	for _, name := range fileNames {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	pkgPath := buildPkg.ImportPath
+	if pkgPath == "." || pkgPath == "" {
+		pkgPath = buildPkg.Name
+	}
+	if modPath := modulePackagePath(dir); modPath != "" {
+		pkgPath = modPath
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(err error) {}, // 容忍类型错误，只要能拿到结构体的定义就行
+	}
+	pkg, _ := conf.Check(pkgPath, fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("type check %s failed", dir)
+	}
+	return pkg, nil
+}
+
+// 通过 go.mod 算出目录对应的包路径，找不到时返回空
+func modulePackagePath(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for cur := absDir; ; cur = filepath.Dir(cur) {
+		if modPath := readModulePath(filepath.Join(cur, "go.mod")); modPath != "" {
+			rel, err := filepath.Rel(cur, absDir)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return modPath
+			}
+			return modPath + "/" + filepath.ToSlash(rel)
+		}
+		if filepath.Dir(cur) == cur {
+			return ""
+		}
+	}
+}
+
+func readModulePath(goModPath string) string {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return ""
+}
+
+type generator struct {
+	pkg       *types.Package
+	qualifier string              // 不在 valuerange 包中生成时，valuerange 的包名前缀
+	done      map[string]struct{} // 已经生成过的类型
+	building  []string            // 正在生成的类型，一层层嵌套下来的，防止递归类型生成互相调用的 default 函数
+	body      bytes.Buffer
+}
+
+func (g *generator) genType(name string) error {
+	if slices.Contains(g.building, name) { // 生成的 default 函数会一直互相调用下去
+		return fmt.Errorf("recursive struct type no support: %s", strings.Join(append(g.building, name), " -> "))
+	}
+	if _, ok := g.done[name]; ok {
+		return nil
+	}
+	g.done[name] = struct{}{}
+	g.building = append(g.building, name)
+	defer func() { g.building = g.building[:len(g.building)-1] }()
+
+	obj := g.pkg.Scope().Lookup(name)
+	if obj == nil {
+		return fmt.Errorf("type %s not found in package %s", name, g.pkg.Path())
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("type %s is not a struct", name)
+	}
+
+	type fieldRule struct {
+		name string
+		expr string
+		todo bool
+	}
+	var rules []fieldRule
+	var skipped []string
+	var nested []string
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		expr, todo, deps, ok := g.ruleExpr(field.Type())
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s %s", field.Name(), types.TypeString(field.Type(), types.RelativeTo(g.pkg))))
+			continue
+		}
+		rules = append(rules, fieldRule{name: field.Name(), expr: expr, todo: todo})
+		nested = append(nested, deps...)
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("type %s has no field can be checked", name)
+	}
+
+	checkerName := name + "Checker"
+	fmt.Fprintf(&g.body, "// %s 对应 %s 的每个字段，由 vrgen 生成\n", checkerName, name)
+	fmt.Fprintf(&g.body, "type %s struct {\n", checkerName)
+	for _, rule := range rules {
+		fmt.Fprintf(&g.body, "\t%s %sValueRangerChecker\n", rule.name, g.qualifier)
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(&g.body, "\t// %s: 类型不支持自动生成，需要手写规则\n", s)
+	}
+	fmt.Fprintf(&g.body, "}\n\n")
+
+	fmt.Fprintf(&g.body, "// 按字段类型填好的默认规则，标了 TODO(vr) 的字段还需要设置真正的值范围\n")
+	fmt.Fprintf(&g.body, "func %s(vr *%sValueRange) %s {\n", defaultFuncName(name), g.qualifier, checkerName)
+	fmt.Fprintf(&g.body, "\treturn %s{\n", checkerName)
+	for _, rule := range rules {
+		todo := ""
+		if rule.todo {
+			todo = " // TODO(vr): 需要设置值范围"
+		}
+		fmt.Fprintf(&g.body, "\t\t%s: %s,%s\n", rule.name, rule.expr, todo)
+	}
+	fmt.Fprintf(&g.body, "\t}\n}\n\n")
+
+	for _, dep := range nested {
+		if err := g.genType(dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 字段类型对应的默认规则表达式
+// todo: 是否还需要设置真正的值范围；deps: 用到的同一个包中的其他结构体，也需要生成
+func (g *generator) ruleExpr(t types.Type) (expr string, todo bool, deps []string, ok bool) {
+	switch tt := types.Unalias(t).(type) {
+	case *types.Basic:
+		info := tt.Info()
+		switch {
+		case info&types.IsInteger != 0 && tt.Kind() != types.Uintptr:
+			return `vr.IntValueRangerChecker("")`, true, nil, true
+		case info&types.IsString != 0:
+			return `vr.StringValueRangerChecker("")`, false, nil, true
+		case info&types.IsBoolean != 0:
+			return `vr.BoolValueRangerChecker("")`, false, nil, true
+		}
+		return "", false, nil, false
+
+	case *types.Slice:
+		elem, todo, deps, ok := g.ruleExpr(tt.Elem())
+		if !ok {
+			return "", false, nil, false
+		}
+		return "vr.ListValueRangerChecker(" + elem + ")", todo, deps, true
+
+	case *types.Array:
+		elem, todo, deps, ok := g.ruleExpr(tt.Elem())
+		if !ok {
+			return "", false, nil, false
+		}
+		return "vr.ListValueRangerChecker(" + elem + ")", todo, deps, true
+
+	case *types.Map:
+		// map 的 key 可以是任意可比较的类型，没有对应 checker 的 key（如 float64）先放一个 any，需要手写规则
+		key, keyTodo, keyDeps, ok := g.ruleExpr(tt.Key())
+		if !ok {
+			key, keyTodo, keyDeps = "vr.AnyValueRangerChecker()", true, nil
+		}
+		elem, elemTodo, deps, ok := g.ruleExpr(tt.Elem())
+		if !ok {
+			return "", false, nil, false
+		}
+		return "vr.MapValueRangerChecker(" + key + ", " + elem + ")", keyTodo || elemTodo, append(keyDeps, deps...), true
+
+	case *types.Pointer:
+		elem, todo, deps, ok := g.ruleExpr(tt.Elem())
+		if !ok {
+			return "", false, nil, false
+		}
+		if _, isStruct := tt.Elem().Underlying().(*types.Struct); isStruct { // StructRange 自己会解开指针
+			return elem, todo, deps, true
+		}
+		// *int32、*string 这样的字段一般是可以不填的
+		return "vr.OptionalValueRangerChecker(" + elem + ")", todo, deps, true
+
+	case *types.Named:
+		if _, isStruct := tt.Underlying().(*types.Struct); isStruct {
+			if tt.Obj().Pkg() != g.pkg || tt.TypeArgs().Len() > 0 { // 只生成同一个包中的结构体
+				return "", false, nil, false
+			}
+			name := tt.Obj().Name()
+			return "vr.StructValueRangerChecker(" + defaultFuncName(name) + "(vr))", false, []string{name}, true
+		}
+		if basic, isBasic := tt.Underlying().(*types.Basic); isBasic && tt.Obj().Pkg() == g.pkg {
+			// 自定义的 type Quality int，内置的 checker 是按 type switch 判断的，通不过，要用泛型的 checker
+			info, name := basic.Info(), tt.Obj().Name()
+			switch {
+			case info&types.IsInteger != 0 && basic.Kind() != types.Uintptr:
+				return g.qualifier + "NewIntChecker[" + name + `]("")`, true, nil, true
+			case info&types.IsString != 0:
+				return g.qualifier + "NewStringChecker[" + name + "]()", false, nil, true
+			case info&types.IsBoolean != 0:
+				return g.qualifier + "NewBoolChecker[" + name + `]("")`, false, nil, true
+			}
+		}
+		return "", false, nil, false
+
+	default:
+		return "", false, nil, false
+	}
+}
+
+func defaultFuncName(typeName string) string {
+	runes := []rune(typeName)
+	runes[0] = unicode.ToUpper(runes[0])
+	return "default" + string(runes) + "Checker"
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("testdata/cfg", []string{"itemCfg"}, false)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := string(src)
+
+	wants := []string{
+		`import valuerange "github.com/chenjinjie/value-range"`,
+		"type itemCfgChecker struct {",
+		"// Weight float64: 类型不支持自动生成，需要手写规则",
+		"func defaultItemCfgChecker(vr *valuerange.ValueRange) itemCfgChecker {",
+		`Id:      vr.IntValueRangerChecker(""), // TODO(vr): 需要设置值范围`,
+		`Name:    vr.StringValueRangerChecker(""),`,
+		`Quality: valuerange.NewIntChecker[Quality](""), // TODO(vr): 需要设置值范围`,
+		`Rewards: vr.ListValueRangerChecker(vr.StructValueRangerChecker(defaultRewardCfgChecker(vr))),`,
+		`Extra:   vr.StructValueRangerChecker(defaultRewardCfgChecker(vr)),`,
+		`Tags:    vr.MapValueRangerChecker(vr.StringValueRangerChecker(""), vr.ListValueRangerChecker(vr.IntValueRangerChecker(""))), // TODO(vr): 需要设置值范围`,
+		// 任意可比较的 key，没有对应 checker 的 key 先放 any
+		`ByPos:   vr.MapValueRangerChecker(vr.StructValueRangerChecker(defaultRewardCfgChecker(vr)), vr.IntValueRangerChecker("")),`,
+		`Flags:   vr.MapValueRangerChecker(vr.BoolValueRangerChecker(""), vr.IntValueRangerChecker("")),`,
+		`Ratios:  vr.MapValueRangerChecker(vr.AnyValueRangerChecker(), vr.IntValueRangerChecker("")),`,
+		// 指向基础类型的指针是可以不填的
+		`Level:   vr.OptionalValueRangerChecker(vr.IntValueRangerChecker("")),`,
+		`Alias:   vr.OptionalValueRangerChecker(vr.StringValueRangerChecker("")),`,
+		"type rewardCfgChecker struct {",
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code missing: %s\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("unexported field should be skipped:\n%s", out)
+	}
+	if strings.Count(out, "type rewardCfgChecker struct") != 1 {
+		t.Errorf("nested struct should be generated once:\n%s", out)
+	}
+}
+
+func TestGenerateInValueRangePackage(t *testing.T) {
+	src, err := generate("../..", []string{"heroCfg"}, true)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	out := string(src)
+	if strings.Contains(out, "valuerange.") || !strings.Contains(out, "func defaultHeroCfgChecker(vr *ValueRange) heroCfgChecker {") {
+		t.Errorf("generated code in valuerange package should not be qualified:\n%s", out)
+	}
+	if _, err := generate("../..", []string{"noSuchCfg"}, true); err == nil {
+		t.Errorf("generate unknown type should fail")
+	}
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"treeCfg", "recursive struct type no support: treeCfg -> treeCfg"},
+		{"questCfg", "recursive struct type no support: questCfg -> questStepCfg -> questCfg"},
+	}
+	for _, c := range cases {
+		_, err := generate("testdata/cfg", []string{c.name}, false)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("generate %s err: %v, want: %s", c.name, err, c.want)
+		}
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	cases := []struct {
+		output    string
+		withTests bool
+		want      string
+	}{
+		{"", false, filepath.Join("cfg", "itemcfg_checker_gen.go")},
+		{"", true, filepath.Join("cfg", "itemcfg_checker_gen_test.go")},
+		{"my_gen.go", true, filepath.Join("cfg", "my_gen.go")},
+	}
+	for _, c := range cases {
+		if got := outputPath("cfg", c.output, "itemCfg", c.withTests); got != c.want {
+			t.Errorf("output: %q, tests: %v, path: %s, want: %s", c.output, c.withTests, got, c.want)
+		}
+	}
+}
+
+// 生成的代码要能编译过，-tests 时类型在 _test.go 中，生成的文件也要是 _test.go
+func TestGenerateBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("go build is slow")
+	}
+	cfgSrc, err := os.ReadFile("testdata/cfg/cfg.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, withTests := range []bool{false, true} {
+		// 要在模块中才能引用到 valuerange 包，testdata 中的目录不会被 ./... 匹配到
+		dir, err := os.MkdirTemp("testdata", "build")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		cfgFile := "cfg.go"
+		if withTests {
+			cfgFile = "cfg_test.go"
+			if err := os.WriteFile(filepath.Join(dir, "doc.go"), []byte("package cfg\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, cfgFile), cfgSrc, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := generate(dir, []string{"itemCfg"}, withTests)
+		if err != nil {
+			t.Fatalf("tests: %v, generate failed: %v", withTests, err)
+		}
+		if err := os.WriteFile(outputPath(dir, "", "itemCfg", withTests), src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		args := []string{"build", "."}
+		if withTests {
+			args = []string{"test", "-count=1", "-run", "^$", "."} // go build 不编译 _test.go
+		}
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("tests: %v, go %s failed: %v\n%s\n%s", withTests, strings.Join(args, " "), err, out, src)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// vrgen 根据配置结构体生成对应的 checker 结构体骨架，配合 go generate 使用：
+//
+//	//go:generate go run github.com/chenjinjie/value-range/cmd/vrgen -type heroCfg,heroSkinCfg
+//
+// 对每个类型 T 生成：
+//  1. TChecker 结构体，每个可以检测的字段对应一个 ValueRangerChecker 字段
+//  2. defaultTChecker(vr) 函数，按字段类型填好默认规则，需要设置真正值范围的字段用 TODO(vr) 标出来
+//
+// 生成的文件每次都会覆盖，不要手改，需要真正的值范围时，在 defaultTChecker 的结果上改字段：
+//
+//	checker := defaultHeroCfgChecker(vr)
+//	checker.Quality = vr.EnumValueRangerChecker("heroCfgQuality")
+//
+// 这样配置结构体加了字段，重新 go generate 就行了，不会和手写的部分冲突
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "逗号分隔的配置结构体类型名，必填")
+	output := flag.String("output", "", "输出文件，默认是 <第一个类型名小写>_checker_gen.go，-tests 时是 _checker_gen_test.go")
+	dir := flag.String("dir", ".", "配置结构体所在的包目录")
+	tests := flag.Bool("tests", false, "同时读取 _test.go 文件中的类型，输出文件也要是 _test.go")
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	names := strings.Split(*typeNames, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+
+	src, err := generate(*dir, names, *tests)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vrgen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(outputPath(*dir, *output, names[0], *tests), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "vrgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// 输出文件的路径，没有指定时按第一个类型名生成，读取了 _test.go 中的类型时输出文件也要是 _test.go，不然编译不过
+func outputPath(dir, output, firstType string, withTests bool) string {
+	outPath := output
+	if outPath == "" {
+		outPath = strings.ToLower(firstType) + "_checker_gen.go"
+		if withTests {
+			outPath = strings.ToLower(firstType) + "_checker_gen_test.go"
+		}
+	}
+	if !filepath.IsAbs(outPath) {
+		outPath = filepath.Join(dir, outPath)
+	}
+	return outPath
+}
@@ -0,0 +1,39 @@
+package cfg
+
+type Quality int32
+
+type rewardCfg struct {
+	ItemId uint32
+	Count  int64
+}
+
+type itemCfg struct {
+	Id      uint32
+	Name    string
+	Quality Quality
+	Weight  float64
+	Rewards []rewardCfg
+	Extra   *rewardCfg
+	Tags    map[string][]int64
+	ByPos   map[rewardCfg]int
+	Flags   map[bool]int
+	Ratios  map[float64]int
+	Level   *int32
+	Alias   *string
+	hidden  int
+}
+
+// 递归的类型，不能生成
+type treeCfg struct {
+	Id       uint32
+	Children []treeCfg
+}
+
+type questCfg struct {
+	Id   uint32
+	Next *questStepCfg
+}
+
+type questStepCfg struct {
+	Quests map[string]questCfg
+}
@@ -0,0 +1,119 @@
+package valuerange
+
+import (
+	"errors"
+	"testing"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+)
+
+type unitCfg struct {
+	Id uint32
+}
+
+type targetCfg struct {
+	Quality int
+	Target  uint32
+	Weights []int
+}
+
+type targetCfgChecker struct {
+	Quality ValueRangerChecker
+	Target  ValueRangerChecker
+	Weights ValueRangerChecker
+}
+
+func TestCombinator(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneEnumCfg("quality", map[uint64]struct{}{1: {}, 2: {}, 3: {}})
+	valueRangeChecker.LoadOneCfg("heroCfg", []unitCfg{{Id: 101}, {Id: 102}})
+	valueRangeChecker.LoadOneCfg("npcCfg", []unitCfg{{Id: 201}})
+
+	even := valueRangeChecker.FuncValueRangerChecker("even", func(value any) error {
+		i, ok := value.(int)
+		if !ok {
+			return errors.New("not an int")
+		}
+		if i%2 != 0 {
+			return errors.New("odd")
+		}
+		return nil
+	})
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.StructValueRangerChecker(targetCfgChecker{
+			// 枚举或者 0
+			Quality: valueRangeChecker.OrValueRangerChecker(
+				valueRangeChecker.EnumValueRangerChecker("quality"),
+				valueRangeChecker.IntValueRangerChecker("[0,0]"),
+			),
+			Target: valueRangeChecker.OrValueRangerChecker(
+				valueRangeChecker.RefValueRangerChecker("heroCfg.Id"),
+				valueRangeChecker.RefValueRangerChecker("npcCfg.Id"),
+			),
+			Weights: valueRangeChecker.ListValueRangerChecker(valueRangeChecker.AndValueRangerChecker(
+				valueRangeChecker.IntValueRangerChecker("[0,100]"),
+				even,
+				valueRangeChecker.NotValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[50,50]")),
+			)),
+		})
+	}
+
+	good := []targetCfg{
+		{Quality: 0, Target: 101, Weights: []int{10, 20}},
+		{Quality: 3, Target: 201},
+	}
+	for _, value := range good {
+		checkBothPaths(t, valueRangeChecker, "target", newChecker, targetCfg{}, value)
+	}
+	checkBothPaths(t, valueRangeChecker, "target", nil, nil, targetCfg{Quality: 9, Target: 301, Weights: []int{101, 50, 7}},
+		".Quality: value 9 match none of or(enum(quality), int[0,0]): [0] value 9 (int) not match enum(quality); [1] value 9 (int) not match int[0,0]",
+		".Target: value 301 match none of or(ref(heroCfg.Id), ref(npcCfg.Id)): [0] value 301 (uint32) not match ref(heroCfg.Id); [1] value 301 (uint32) not match ref(npcCfg.Id)",
+		".Weights[0]: value 101 (int) not match int[0,100]",
+		".Weights[0]: func(even): odd",
+		".Weights[1]: value 50 (int) should not match int[50,50]",
+		".Weights[2]: func(even): odd",
+	)
+
+	// or 的分支是容器时，分支中的路径是相对这个值的
+	listOrInt := valueRangeChecker.OrValueRangerChecker(
+		valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[1,3]")),
+		valueRangeChecker.IntValueRangerChecker("[1,3]"),
+	)
+	valueRangeChecker.RegChecker("listOrInt", listOrInt)
+	for _, value := range []any{2, []int{1, 3}} {
+		if !valueRangeChecker.Check("listOrInt", value) {
+			t.Errorf("listOrInt %v should pass", value)
+		}
+	}
+	violations := valueRangeChecker.CheckWithReport("listOrInt", []int{1, 5})
+	want := "listOrInt: value [1 5] match none of or(list<int[1,3]>, int[1,3]): [0] [1]: value 5 (int) not match int[1,3]; [1] value [1 5] ([]int) not match int[1,3]"
+	if len(violations) != 1 || violations[0].String() != want {
+		t.Errorf("listOrInt violations: %v, want: %s", violations, want)
+	}
+
+	// any 什么值都通过，只检测 map 的 key
+	valueRangeChecker.RegChecker("keyOnly", valueRangeChecker.MapValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[1,3]"), valueRangeChecker.AnyValueRangerChecker()))
+	if !valueRangeChecker.Check("keyOnly", map[int]any{1: "a", 2: nil, 3: []int{}}) || valueRangeChecker.Check("keyOnly", map[int]any{4: 1}) {
+		t.Errorf("keyOnly check wrong")
+	}
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.AndValueRangerChecker() },
+		func() { valueRangeChecker.OrValueRangerChecker(valueRangeChecker.IntValueRangerChecker(""), nil) },
+		func() { valueRangeChecker.NotValueRangerChecker(nil) },
+		func() { valueRangeChecker.FuncValueRangerChecker("nil", nil) },
+		// 直接用内部包时可以不加 checker，检测、编译时才发现
+		func() { basetyperange.AndValueRangerChecker().Check(1) },
+		func() { basetyperange.OrValueRangerChecker().Check(1) },
+		func() { valueRangeChecker.CompileChecker(basetyperange.AndValueRangerChecker(), 1) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,107 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// 一张 hero 规模的配置表，1000 行
+func bigHeroCfgList() []heroCfg {
+	list := make([]heroCfg, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		row := heroCfgList[i%len(heroCfgList)]
+		row.Id = uint64(70000 + i)
+		list = append(list, row)
+	}
+	return list
+}
+
+// 同一个规则的普通 checker 和编译到 sample 类型上的 checker 检测 value，结果都要是 wants
+// 第一次用 name 时注册为 name 和 compiledName，之后再用这个 name 时 newChecker、sample 不用传
+// wants 中是去掉了开头的注册名的诊断信息，如 ".Slots[1]: value 9 (uint64) not match ref(itemCfg.Id)"，中间出现的注册名写成 {key}；没有 wants 时要通过
+func checkBothPaths(t *testing.T, vr *ValueRange, name string, newChecker func() ValueRangerChecker, sample, value any, wants ...string) {
+	t.Helper()
+	compiledName := "compiled" + strings.ToUpper(name[:1]) + name[1:]
+	if _, ok := vr.checkerStore[name]; !ok {
+		vr.RegChecker(name, newChecker())
+		vr.RegChecker(compiledName, vr.CompileChecker(newChecker(), sample))
+	}
+	for _, key := range []string{name, compiledName} {
+		if vr.Check(key, value) != (len(wants) == 0) {
+			t.Errorf("%s %v check should pass: %v", key, value, len(wants) == 0)
+		}
+		violations := vr.CheckWithReport(key, value)
+		if len(violations) != len(wants) {
+			t.Errorf("%s %v violations: %v, want: %v", key, value, violations, wants)
+			continue
+		}
+		for i, want := range wants {
+			if want = key + strings.ReplaceAll(want, "{key}", key); violations[i].String() != want {
+				t.Errorf("%s violation %d: %s, want: %s", key, i, violations[i].String(), want)
+			}
+		}
+	}
+}
+
+func TestCompileChecker(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+	checker := newHeroCfgListChecker(valueRangeChecker)
+	compiled := valueRangeChecker.CompileChecker(checker, heroCfgList)
+
+	if !compiled.Check(heroCfgList) || !compiled.Check(bigHeroCfgList()) {
+		t.Fatalf("compiled checker check heroCfg failed")
+	}
+
+	// 不通过的时候，诊断信息要和没编译的一样
+	badHeroCfgList := []heroCfg{
+		heroCfgList[0],
+		{Id: 61404, Desc: "bad", Quality: 7, Skins: []uint64{6140101, 9}, Attrs: map[uint32]uint32{1: 0, 2: 1}},
+	}
+	const key = "badHeroCfg"
+	valueRangeChecker.RegChecker(key, checker)
+	valueRangeChecker.RegChecker(key+"Compiled", compiled)
+	want := valueRangeChecker.CheckWithReport(key, badHeroCfgList)
+	got := valueRangeChecker.CheckWithReport(key+"Compiled", badHeroCfgList)
+	if len(want) != 3 || len(got) != len(want) {
+		t.Fatalf("violations: %v, compiled violations: %v", want, got)
+	}
+	for i := range want {
+		if strings.TrimPrefix(want[i].Path, key) != strings.TrimPrefix(got[i].Path, key+"Compiled") || want[i].Msg != got[i].Msg {
+			t.Errorf("violation %d: %v, compiled: %v", i, want[i], got[i])
+		}
+	}
+
+	// 类型和编译时不一致的，退回到原来的 checker
+	if compiled.Check(heroSkinCfgList) != checker.Check(heroSkinCfgList) {
+		t.Errorf("compiled checker fallback mismatch")
+	}
+	if compiled.Check([]heroCfg{{Quality: 7}}) {
+		t.Errorf("compiled checker should fail on bad quality")
+	}
+}
+
+func BenchmarkHeroCfgCheck(b *testing.B) {
+	valueRangeChecker := newHeroValueRange(b)
+	checker := newHeroCfgListChecker(valueRangeChecker)
+	list := bigHeroCfgList()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !checker.Check(list) {
+			b.Fatalf("check failed")
+		}
+	}
+}
+
+func BenchmarkHeroCfgCheckCompiled(b *testing.B) {
+	valueRangeChecker := newHeroValueRange(b)
+	checker := valueRangeChecker.CompileChecker(newHeroCfgListChecker(valueRangeChecker), []heroCfg(nil))
+	list := bigHeroCfgList()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !checker.Check(list) {
+			b.Fatalf("check failed")
+		}
+	}
+}
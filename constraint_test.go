@@ -0,0 +1,133 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+type dungeonCfg struct {
+	Id       uint64
+	MinLevel int
+	MaxLevel int
+	Open     bool
+	Quality  int
+	Tag      heroTagCfg
+}
+
+type dungeonCfgChecker struct {
+	Id       ValueRangerChecker
+	MinLevel ValueRangerChecker
+	MaxLevel ValueRangerChecker
+}
+
+func TestConstraint(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithConstraints(valueRangeChecker.StructValueRangerChecker(dungeonCfgChecker{
+			Id:       valueRangeChecker.IntValueRangerChecker(""),
+			MinLevel: valueRangeChecker.IntValueRangerChecker("[1,-]"),
+			MaxLevel: valueRangeChecker.IntValueRangerChecker("[1,-]"),
+		}),
+			valueRangeChecker.ExprConstraint("MinLevel <= MaxLevel"),
+			valueRangeChecker.ExprConstraint("if Open then Quality >= 3"),
+			TypedConstraint("free dungeon is not open", func(row dungeonCfg) bool {
+				return !row.Tag.Free || !row.Open
+			}, "Tag.Free", "Open"),
+		)
+	}
+	valueRangeChecker.RegChecker("dungeon", newChecker())
+	valueRangeChecker.RegChecker("compiledDungeon", valueRangeChecker.CompileChecker(newChecker(), dungeonCfg{}))
+
+	good := dungeonCfg{Id: 1, MinLevel: 1, MaxLevel: 10, Open: true, Quality: 3}
+	bad := dungeonCfg{Id: 2, MinLevel: 10, MaxLevel: 5, Open: true, Quality: 1, Tag: heroTagCfg{Free: true}}
+	wants := []string{
+		".MinLevel: constraint MinLevel <= MaxLevel not match: MinLevel=10, MaxLevel=5",
+		".Open: constraint if Open then Quality >= 3 not match: Open=true, Quality=1",
+		".Tag.Free: constraint free dungeon is not open not match: Tag.Free=true, Open=true",
+	}
+	for _, key := range []string{"dungeon", "compiledDungeon"} {
+		if violations := valueRangeChecker.CheckWithReport(key, &good); len(violations) != 0 {
+			t.Errorf("%s good violations: %v", key, violations)
+		}
+		violations := valueRangeChecker.CheckWithReport(key, bad)
+		if len(violations) != len(wants) {
+			t.Errorf("%s violations: %v", key, violations)
+			continue
+		}
+		for i, want := range wants {
+			if violations[i].String() != key+want {
+				t.Errorf("%s violation %d: %s, want: %s", key, i, violations[i].String(), key+want)
+			}
+		}
+	}
+
+	// map[string]any 的行也可以用表达式，缺少字段时是约束出错
+	mapChecker := valueRangeChecker.WithConstraints(valueRangeChecker.StructValueRangerChecker(struct{ MinLevel ValueRangerChecker }{
+		MinLevel: valueRangeChecker.IntValueRangerChecker(""),
+	}), valueRangeChecker.ExprConstraint("MinLevel <= MaxLevel"))
+	if !mapChecker.Check(map[string]any{"MinLevel": 1, "MaxLevel": 2.0}) || mapChecker.Check(map[string]any{"MinLevel": 3, "MaxLevel": 2}) {
+		t.Errorf("map row constraint check failed")
+	}
+	valueRangeChecker.RegChecker("mapDungeon", mapChecker)
+	violations := valueRangeChecker.CheckWithReport("mapDungeon", map[string]any{"MinLevel": 3})
+	if len(violations) != 1 || !strings.Contains(violations[0].String(), "mapDungeon.MinLevel: constraint MinLevel <= MaxLevel error: value has no key: MaxLevel") {
+		t.Errorf("map row violations: %v", violations)
+	}
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.ExprConstraint("MinLevel <=") },
+		func() { valueRangeChecker.WithConstraints(valueRangeChecker.IntValueRangerChecker("")) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestConstraintRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg("dungeonCfg", []dungeonCfg{
+		{Id: 1, MinLevel: 1, MaxLevel: 10},
+		{Id: 2, MinLevel: 10, MaxLevel: 5, Tag: heroTagCfg{Free: true}},
+	})
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"dungeonCfg": {"list": {"struct": {"Id": "int", "MinLevel": "int", "MaxLevel": "int"}, "checks": ["MinLevel <= MaxLevel", "!Tag.Free"]}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	wants := []string{
+		"dungeonCfg[1].MinLevel: constraint MinLevel <= MaxLevel not match: MinLevel=10, MaxLevel=5",
+		"dungeonCfg[1].Tag.Free: constraint !Tag.Free not match: Tag.Free=true",
+	}
+	violations := report.Tables[0].Violations
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if !strings.Contains(violations[i].String(), want) {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "checks": ["Id <"]}}}`, `rules.json:1:55: check "Id <" illegal: col`},
+		{`{"tables": {"a": {"list": "int", "checks": ["Id > 0"]}}}`, `rules.json:1:34: checks can only be used with struct`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "checks": "Id > 0"}}}`, `checks must be an array of expr`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package valuerange
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	csvtable "github.com/chenjinjie/value-range/internal/csv-table"
+)
+
+// csv 的解析选项，零值就是默认的 , ; :
+type CSVOptions = csvtable.Options
+
+// 从 csv 加载一张配置表，表头对应 sample 的字段，格式见 internal/csv-table
+// sample 是行结构体或行结构体的 slice，返回解析出来的 []行结构体，同时也通过 LoadOneCfg 加载了进来
+// 之后检测这张表时，不通过的值会带上在 csv 中的位置，如 hero.csv:17:C
+func (vr *ValueRange) LoadOneCSVCfg(cfgKey string, file string, r io.Reader, sample any, opts CSVOptions) (any, error) {
+	rowsType := reflect.TypeOf(sample)
+	if rowsType == nil {
+		return nil, fmt.Errorf("LoadOneCSVCfg sample is nil")
+	}
+	if rowsType.Kind() == reflect.Struct {
+		rowsType = reflect.SliceOf(rowsType)
+	}
+
+	table, err := csvtable.Decode(file, r, rowsType, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !vr.LoadOneCfg(cfgKey, table.Rows) {
+		return nil, fmt.Errorf("%s: load cfg %s failed, duplicate key", file, cfgKey)
+	}
+	vr.locators[cfgKey] = table
+	return table.Rows, nil
+}
+
+// 同 LoadOneCSVCfg，从文件中读取
+func (vr *ValueRange) LoadOneCSVFile(cfgKey string, path string, sample any, opts CSVOptions) (any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return vr.LoadOneCSVCfg(cfgKey, path, f, sample, opts)
+}
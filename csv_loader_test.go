@@ -0,0 +1,112 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+const heroCSV = `Id,Desc,Quality,Open,Tag.Free,Skins,Attrs
+61401,top,1,true,true,6140101|6140102,1=100|2=200
+61402,ace,7,true,false,6140201|9,1=150|3=250
+`
+
+func TestLoadOneCSVCfg(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList)
+	rows, err := valueRangeChecker.LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(heroCSV), heroCfg{}, CSVOptions{ListSep: "|", KVSep: "="})
+	if err != nil {
+		t.Fatalf("load hero.csv failed: %v", err)
+	}
+	heroRows := rows.([]heroCfg)
+	if len(heroRows) != 2 || !heroRows[0].Tag.Free || heroRows[1].Skins[1] != 9 || heroRows[0].Attrs[2] != 200 {
+		t.Fatalf("hero.csv rows: %+v", heroRows)
+	}
+
+	if err := valueRangeChecker.LoadRuleFile("testdata/rules.json"); err != nil {
+		t.Fatalf("load rule file failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	var violations []Violation
+	for _, table := range report.Tables {
+		violations = append(violations, table.Violations...)
+	}
+	wantPos := []string{"hero.csv:3:C", "hero.csv:3:F", "hero.csv:3:G"}
+	if len(violations) != len(wantPos) {
+		t.Fatalf("violations: %v", violations)
+	}
+	for i, want := range wantPos {
+		if violations[i].Pos != want {
+			t.Errorf("violation %d pos: %s, want: %s", i, violations[i].Pos, want)
+		}
+	}
+
+	// 不是检测加载进来的那份数据时，没有位置信息
+	for _, v := range valueRangeChecker.CheckWithReport(heroCfgKey, heroRows) {
+		if v.Pos != "" {
+			t.Errorf("violation should have no pos: %v", v)
+		}
+	}
+}
+
+func TestLoadOneCSVCfgMalformed(t *testing.T) {
+	cases := []struct {
+		csv  string
+		want string
+	}{
+		{"Id,Level\n1,2\n", `hero.csv:1:B: column "Level": no exported field "Level"`},
+		{"Id,Tag\n1,true\n", `hero.csv:1:B: column "Tag" is a struct, use columns like Tag.Field`},
+		{"Id,Quality\n1,2\n-1,3\n", `hero.csv:3:A: Id: parse uint64 "-1" failed`},
+		{"Id,Attrs\n1,1;2\n", `hero.csv:2:B: Attrs: map item "1" must be key:value`},
+		{"Id,Desc,Id\n1,a,1\n", `hero.csv:1:C: column "Id" duplicate, same field as column A`},
+	}
+	for _, c := range cases {
+		_, err := ValueRangeChecker().LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(c.csv), []heroCfg(nil), CSVOptions{})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("csv: %q\nerr: %v\nwant: %s", c.csv, err, c.want)
+		}
+	}
+}
+
+type csvBaseCfg struct {
+	Id uint64
+}
+
+type CSVBaseCfg struct {
+	Id uint64
+}
+
+type csvTagCfg struct {
+	Free bool
+}
+
+func TestLoadOneCSVCfgEmbeddedPointer(t *testing.T) {
+	// 不导出的嵌入指针没法分配，表头就报错，不能 panic
+	type hiddenBaseCfg struct {
+		*csvBaseCfg
+		Desc string
+	}
+	_, err := ValueRangeChecker().LoadOneCSVCfg("hidden", "hidden.csv", strings.NewReader("Desc,Id\na,1\n"), hiddenBaseCfg{}, CSVOptions{})
+	want := `hidden.csv:1:B: column "Id": field "Id" is in unexported embedded pointer *valuerange.csvBaseCfg, can not be set`
+	if err == nil || err.Error() != want {
+		t.Errorf("err: %v\nwant: %s", err, want)
+	}
+
+	// 导出的嵌入指针和结构体指针字段在解析时分配
+	type baseCfg struct {
+		*CSVBaseCfg
+		Desc string
+		Tag  *csvTagCfg
+	}
+	rows, err := ValueRangeChecker().LoadOneCSVCfg("base", "base.csv", strings.NewReader("Id,Desc,Tag.Free\n1,a,true\n"), baseCfg{}, CSVOptions{})
+	if err != nil {
+		t.Fatalf("load base.csv failed: %v", err)
+	}
+	baseRows := rows.([]baseCfg)
+	if len(baseRows) != 1 || baseRows[0].Id != 1 || baseRows[0].Desc != "a" || !baseRows[0].Tag.Free {
+		t.Errorf("base.csv rows: %+v", baseRows)
+	}
+	_, err = ValueRangeChecker().LoadOneCSVCfg("base", "base.csv", strings.NewReader("Id,CSVBaseCfg.Id\n1,1\n"), baseCfg{}, CSVOptions{})
+	if err == nil || !strings.Contains(err.Error(), `base.csv:1:B: column "CSVBaseCfg.Id" duplicate, same field as column A`) {
+		t.Errorf("duplicate embedded column err: %v", err)
+	}
+}
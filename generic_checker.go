@@ -0,0 +1,355 @@
+package valuerange
+
+import (
+	"fmt"
+	"sort"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+	expandtyperange "github.com/chenjinjie/value-range/internal/expand-type-range"
+)
+
+// 泛型的 checker，包了一层 base-type-range、expand-type-range 中的 checker
+// 值的类型在编译期就确定了，传错类型直接编译不过，检测的时候也不用把值装箱成 any 再做 type switch
+// 同时也实现了 ValueRangerChecker，可以继续放到 struct、list、map 等 checker 中使用
+
+// 整数类型约束，和 golang.org/x/exp/constraints.Integer 一样，不想为这个引入外部依赖
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+type Checker[T any] interface {
+	ValueRangerChecker
+	CheckValue(value T) bool
+}
+
+// T 是不是有符号整数
+func isSigned[T Integer]() bool {
+	var v T
+	v--
+	return v < 0
+}
+
+// 泛型 checker 的 Check(any)，类型不对直接不通过
+func checkAny[T any](checker Checker[T], value any) bool {
+	v, ok := value.(T)
+	if !ok {
+		basetyperange.Debugf("%s check value type not match, type: %T\n", checker.ToString(), value)
+		return false
+	}
+	return checker.CheckValue(v)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type IntChecker[T Integer] struct {
+	ir *basetyperange.IntRange
+}
+
+func NewIntChecker[T Integer](pattern string) *IntChecker[T] {
+	return &IntChecker[T]{ir: basetyperange.IntValueRangerChecker(pattern)}
+}
+
+func (ic *IntChecker[T]) CheckValue(value T) bool {
+	if value < 0 {
+		return ic.ir.CheckInt64(int64(value))
+	}
+	return ic.ir.CheckUint64(uint64(value))
+}
+
+func (ic *IntChecker[T]) Check(value any) bool {
+	return checkAny[T](ic, value)
+}
+
+func (ic *IntChecker[T]) ToString() string {
+	return ic.ir.ToString()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type StringChecker[T ~string] struct{}
+
+func NewStringChecker[T ~string]() *StringChecker[T] {
+	return &StringChecker[T]{}
+}
+
+func (sc *StringChecker[T]) CheckValue(value T) bool {
+	return true
+}
+
+func (sc *StringChecker[T]) Check(value any) bool {
+	return checkAny[T](sc, value)
+}
+
+func (sc *StringChecker[T]) ToString() string {
+	return "string"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type BoolChecker[T ~bool] struct {
+	br *basetyperange.BoolRange
+}
+
+func NewBoolChecker[T ~bool](rangeStr string) *BoolChecker[T] {
+	return &BoolChecker[T]{br: basetyperange.BoolValueRangerChecker(rangeStr)}
+}
+
+func (bc *BoolChecker[T]) CheckValue(value T) bool {
+	return bc.br.Check(bool(value))
+}
+
+func (bc *BoolChecker[T]) Check(value any) bool {
+	return checkAny[T](bc, value)
+}
+
+func (bc *BoolChecker[T]) ToString() string {
+	return bc.br.ToString()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type EnumChecker[T Integer] struct {
+	er *expandtyperange.EnumRange
+}
+
+func NewEnumChecker[T Integer](vr *ValueRange, enumKey string) *EnumChecker[T] {
+	return &EnumChecker[T]{er: expandtyperange.EnumValueRangerChecker(vr.enumStore, enumKey)}
+}
+
+func (ec *EnumChecker[T]) CheckValue(value T) bool {
+	if value < 0 { // 枚举都是非负的
+		return false
+	}
+	return ec.er.CheckUint64(uint64(value))
+}
+
+func (ec *EnumChecker[T]) Check(value any) bool {
+	return checkAny[T](ec, value)
+}
+
+func (ec *EnumChecker[T]) ToString() string {
+	return ec.er.ToString()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// 整数的 ref 检测，被引用的字段是有符号的还是无符号的，要和 T 保持一致
+type RefChecker[T Integer] struct {
+	rf     *expandtyperange.RefRange
+	signed bool
+}
+
+func NewRefChecker[T Integer](vr *ValueRange, rangeStr string) *RefChecker[T] {
+	return &RefChecker[T]{
+		rf:     expandtyperange.RefValueRangerChecker(vr.refStore, rangeStr),
+		signed: isSigned[T](),
+	}
+}
+
+func (rc *RefChecker[T]) CheckValue(value T) bool {
+	if rc.signed {
+		return rc.rf.CheckInt64(int64(value))
+	}
+	return rc.rf.CheckUint64(uint64(value))
+}
+
+func (rc *RefChecker[T]) Check(value any) bool {
+	return checkAny[T](rc, value)
+}
+
+func (rc *RefChecker[T]) ToString() string {
+	return rc.rf.ToString()
+}
+
+// 字符串的 ref 检测
+type RefStrChecker[T ~string] struct {
+	rf *expandtyperange.RefRange
+}
+
+func NewRefStrChecker[T ~string](vr *ValueRange, rangeStr string) *RefStrChecker[T] {
+	return &RefStrChecker[T]{rf: expandtyperange.RefValueRangerChecker(vr.refStore, rangeStr)}
+}
+
+func (rc *RefStrChecker[T]) CheckValue(value T) bool {
+	return rc.rf.CheckString(string(value))
+}
+
+func (rc *RefStrChecker[T]) Check(value any) bool {
+	return checkAny[T](rc, value)
+}
+
+func (rc *RefStrChecker[T]) ToString() string {
+	return rc.rf.ToString()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type SliceChecker[T any] struct {
+	elemChecker Checker[T]
+}
+
+func NewSliceChecker[T any](elemChecker Checker[T]) *SliceChecker[T] {
+	return &SliceChecker[T]{elemChecker: elemChecker}
+}
+
+func (sc *SliceChecker[T]) CheckValue(value []T) bool {
+	for i := range value {
+		if !sc.elemChecker.CheckValue(value[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sc *SliceChecker[T]) Check(value any) bool {
+	return checkAny[[]T](sc, value)
+}
+
+func (sc *SliceChecker[T]) CheckWithReport(value any, report *basetyperange.Report) bool {
+	values, ok := value.([]T)
+	if !ok {
+		report.Failf("value type not match %s, is: %T", sc.ToString(), value)
+		return false
+	}
+	pass := true
+	for i := range values {
+		report.PushIndex(i)
+		ok := basetyperange.CheckWithReport(sc.elemChecker, values[i], report)
+		report.Pop()
+		pass = pass && ok
+	}
+	return pass
+}
+
+func (sc *SliceChecker[T]) ToString() string {
+	return "list<" + sc.elemChecker.ToString() + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type MapChecker[K comparable, V any] struct {
+	keyChecker   Checker[K]
+	valueChecker Checker[V]
+}
+
+func NewMapChecker[K comparable, V any](keyChecker Checker[K], valueChecker Checker[V]) *MapChecker[K, V] {
+	return &MapChecker[K, V]{keyChecker: keyChecker, valueChecker: valueChecker}
+}
+
+func (mc *MapChecker[K, V]) CheckValue(value map[K]V) bool {
+	for k, v := range value {
+		if !mc.keyChecker.CheckValue(k) || !mc.valueChecker.CheckValue(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (mc *MapChecker[K, V]) Check(value any) bool {
+	return checkAny[map[K]V](mc, value)
+}
+
+func (mc *MapChecker[K, V]) CheckWithReport(value any, report *basetyperange.Report) bool {
+	values, ok := value.(map[K]V)
+	if !ok {
+		report.Failf("value type not match %s, is: %T", mc.ToString(), value)
+		return false
+	}
+	keys := make([]K, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { // 排个序，让报告的顺序是稳定的
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	pass := true
+	for _, k := range keys {
+		report.PushKey(k)
+		ok := basetyperange.CheckWithReport(mc.keyChecker, k, report) && basetyperange.CheckWithReport(mc.valueChecker, values[k], report)
+		report.Pop()
+		pass = pass && ok
+	}
+	return pass
+}
+
+func (mc *MapChecker[K, V]) ToString() string {
+	return "map<" + mc.keyChecker.ToString() + "," + mc.valueChecker.ToString() + ">"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// 用一个 Go 函数作为检测规则，值是 T 类型的，不用再断言
+// 和其他泛型 checker 一样只有通过、不通过，要在报告中带上不通过的原因时用 ValueRange.FuncValueRangerChecker
+type FuncChecker[T any] struct {
+	desc string
+	fn   func(value T) bool
+}
+
+func NewFuncChecker[T any](desc string, fn func(value T) bool) *FuncChecker[T] {
+	if fn == nil {
+		panic("NewFuncChecker fn is nil")
+	}
+	return &FuncChecker[T]{desc: desc, fn: fn}
+}
+
+func (fc *FuncChecker[T]) CheckValue(value T) bool {
+	return fc.fn(value)
+}
+
+func (fc *FuncChecker[T]) Check(value any) bool {
+	return checkAny[T](fc, value)
+}
+
+func (fc *FuncChecker[T]) ToString() string {
+	return fc.desc
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// 把一个普通的 checker 标上类型，如 struct checker，CheckValue 时还是会走原来的 Check
+type TypedChecker[T any] struct {
+	checker ValueRangerChecker
+}
+
+func Typed[T any](checker ValueRangerChecker) *TypedChecker[T] {
+	if checker == nil {
+		panic("Typed checker is nil")
+	}
+	return &TypedChecker[T]{checker: checker}
+}
+
+func (tc *TypedChecker[T]) CheckValue(value T) bool {
+	return tc.checker.Check(value)
+}
+
+func (tc *TypedChecker[T]) Check(value any) bool {
+	return checkAny[T](tc, value)
+}
+
+func (tc *TypedChecker[T]) CheckWithReport(value any, report *basetyperange.Report) bool {
+	if _, ok := value.(T); !ok {
+		report.Failf("value type not match %s, is: %T", tc.ToString(), value)
+		return false
+	}
+	return basetyperange.CheckWithReport(tc.checker, value, report)
+}
+
+func (tc *TypedChecker[T]) ToString() string {
+	return tc.checker.ToString()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// 带类型的约束，row 是 T 时才调用 fn，否则不通过；row 是解开指针后的结构体值，T 不要用指针
+func TypedConstraint[T any](desc string, fn func(row T) bool, fields ...string) *Constraint {
+	if fn == nil {
+		panic("TypedConstraint fn is nil")
+	}
+	return basetyperange.FuncConstraint(desc, func(row any) bool {
+		if value, ok := row.(T); ok {
+			return fn(value)
+		}
+		return false
+	}, fields...)
+}
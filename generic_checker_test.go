@@ -0,0 +1,57 @@
+package valuerange
+
+import (
+	"testing"
+)
+
+type heroQuality uint8
+
+func TestGenericChecker(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+
+	quality := NewEnumChecker[heroQuality](valueRangeChecker, enumHeroCfgQualityKey)
+	if !quality.CheckValue(3) || quality.CheckValue(7) {
+		t.Errorf("enum checker on named type failed")
+	}
+
+	skins := NewSliceChecker[uint64](NewRefChecker[uint64](valueRangeChecker, heroSkinCfgKey+".Id"))
+	for _, row := range heroCfgList {
+		if !skins.CheckValue(row.Skins) {
+			t.Errorf("skins check failed: %v", row.Skins)
+		}
+	}
+	if skins.CheckValue([]uint64{6140101, 1}) {
+		t.Errorf("skins check should fail on unknown skin")
+	}
+
+	attrs := NewMapChecker[uint32, uint32](NewEnumChecker[uint32](valueRangeChecker, enumHeroCfgAttr), NewIntChecker[uint32]("(0,-)"))
+	if !attrs.CheckValue(map[uint32]uint32{1: 100}) || attrs.CheckValue(map[uint32]uint32{3: 100}) {
+		t.Errorf("attrs check failed")
+	}
+
+	// 泛型 checker 也可以放到普通的 struct checker 中
+	rowChecker := valueRangeChecker.StructValueRangerChecker(heroCfgChecker{
+		Id:      NewIntChecker[uint64]("[61401,61403]"),
+		Desc:    NewStringChecker[string](),
+		Quality: NewEnumChecker[int](valueRangeChecker, enumHeroCfgQualityKey),
+		Open:    NewBoolChecker[bool](""),
+		Tag:     Typed[heroTagCfg](valueRangeChecker.StructValueRangerChecker(heroTagCfgChecker{Free: NewBoolChecker[bool]("")})),
+		Skins:   skins,
+		Attrs:   attrs,
+	})
+	rows := NewSliceChecker[heroCfg](Typed[heroCfg](rowChecker))
+	if !rows.CheckValue(heroCfgList) {
+		t.Errorf("heroCfg check failed")
+	}
+
+	// 通过 any 传进来的值类型不对，不通过
+	if NewIntChecker[int32]("").Check(int64(1)) {
+		t.Errorf("int32 checker should reject int64 value")
+	}
+
+	valueRangeChecker.RegChecker("generic", rows)
+	violations := valueRangeChecker.CheckWithReport("generic", []heroCfg{{Id: 1, Quality: 1}})
+	if len(violations) != 1 || violations[0].Path != "generic[0].Id" {
+		t.Errorf("violations: %v", violations)
+	}
+}
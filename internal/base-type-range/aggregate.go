@@ -0,0 +1,212 @@
+package basetyperange
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+)
+
+/*
+列表、map 中所有元素汇总起来的值要在范围内，范围和 IntRange 的区间写法一样
+
+ 1. SumAggregate       - 和，如掉落权重加起来正好 10000：sum(Weight) [10000,10000]
+ 2. MinAggregate       - 最小值
+ 3. MaxAggregate       - 最大值，如单项属性分配不能超过 100
+ 4. CountAggregate     - 满足表达式的行数，如 Type == "item" 的奖励最多 3 个
+ 5. CountFuncAggregate - 满足 Go 函数的行数
+
+没有字段时是元素本身，否则是行中的字段，嵌套结构体的字段如 Tag.Weight；值只能是整数
+容器是空的时候 min、max 不检测，要求不能为空用 WithLen
+不通过时记在容器本身上，如：
+dropCfg[2].Items: sum(Weight) 9000 not match [10000,10000]
+.
+*/
+
+type Aggregate struct {
+	kind       string // sum、min、max、count
+	field      string // sum、min、max 的字段，为空时是元素本身
+	desc       string // count 的条件，用于报告
+	where      func(row reflect.Value) (bool, error)
+	valueRange *IntRange
+}
+
+func SumAggregate(field, rangeStr string) *Aggregate {
+	return newAggregate("sum", field, rangeStr)
+}
+
+func MinAggregate(field, rangeStr string) *Aggregate {
+	return newAggregate("min", field, rangeStr)
+}
+
+func MaxAggregate(field, rangeStr string) *Aggregate {
+	return newAggregate("max", field, rangeStr)
+}
+
+// where 为空时是所有的行，表达式的语法见 internal/field-expr，表达式不合法时 panic
+func CountAggregate(where, rangeStr string) *Aggregate {
+	a := newAggregate("count", "", rangeStr)
+	if where == "" {
+		return a
+	}
+	e, err := fieldexpr.Parse(where)
+	if err != nil {
+		panic(fmt.Sprintf("count expr illegal: %s, err: %v", where, err))
+	}
+	a.desc = where
+	a.where = func(row reflect.Value) (bool, error) {
+		return e.Eval(func(name string) (any, error) {
+			return rowField(row, name)
+		})
+	}
+	return a
+}
+
+// row 是元素的值（指针已经解开了）
+func CountFuncAggregate(desc string, fn func(row any) bool, rangeStr string) *Aggregate {
+	if fn == nil {
+		panic("CountFuncAggregate fn is nil")
+	}
+	a := newAggregate("count", "", rangeStr)
+	a.desc = desc
+	a.where = func(row reflect.Value) (bool, error) {
+		value, err := elemValue(row)
+		if err != nil {
+			return false, err
+		}
+		return fn(value), nil
+	}
+	return a
+}
+
+func newAggregate(kind, field, rangeStr string) *Aggregate {
+	if rangeStr == "" {
+		panic(kind + " range is empty")
+	}
+	return &Aggregate{kind: kind, field: field, valueRange: IntValueRangerChecker(rangeStr)}
+}
+
+func (a *Aggregate) ToString() string {
+	return a.name() + " " + a.valueRange.originalStr
+}
+
+// 如 sum(Weight)、count(Type == "item")、max
+func (a *Aggregate) name() string {
+	switch {
+	case a.desc != "":
+		return a.kind + "(" + a.desc + ")"
+	case a.field != "":
+		return a.kind + "(" + a.field + ")"
+	default:
+		return a.kind
+	}
+}
+
+func (lr *ListRange) AddAggregate(aggregate *Aggregate) *ListRange {
+	if aggregate == nil {
+		panic("list range aggregate is nil")
+	}
+	lr.aggregates = append(lr.aggregates, aggregate)
+	return lr
+}
+
+func (mr *MapRange) AddAggregate(aggregate *Aggregate) *MapRange {
+	if aggregate == nil {
+		panic("map range aggregate is nil")
+	}
+	mr.aggregates = append(mr.aggregates, aggregate)
+	return mr
+}
+
+func checkAggregates(aggregates []*Aggregate, rows []uniqueRow, report *Report) bool {
+	pass := true
+	for _, a := range aggregates {
+		if a.check(rows, report) {
+			continue
+		}
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+func (a *Aggregate) check(rows []uniqueRow, report *Report) bool {
+	value, empty, err := a.value(rows)
+	var msg string
+	switch {
+	case err != nil:
+		msg = fmt.Sprintf("%s error: %v", a.name(), err)
+	case empty, a.valueRange.CheckInt64(value):
+		return true
+	default:
+		msg = fmt.Sprintf("%s %d not match %s", a.name(), value, a.valueRange.originalStr)
+	}
+	Debugf("%s\n", msg)
+	report.Failf("%s", msg)
+	return false
+}
+
+// 汇总的值，empty 表示 min、max 没有值
+func (a *Aggregate) value(rows []uniqueRow) (int64, bool, error) {
+	var result int64
+	found := false
+	for _, row := range rows {
+		if a.kind == "count" {
+			if a.where == nil {
+				result++
+				continue
+			}
+			ok, err := a.where(row.value)
+			if err != nil {
+				return 0, false, fmt.Errorf("at %s: %v", row.seg.String(), err)
+			}
+			if ok {
+				result++
+			}
+			continue
+		}
+
+		i64Value, err := a.rowValue(row.value)
+		if err != nil {
+			return 0, false, fmt.Errorf("at %s: %v", row.seg.String(), err)
+		}
+		switch {
+		case a.kind == "sum":
+			if (i64Value > 0 && result > math.MaxInt64-i64Value) || (i64Value < 0 && result < math.MinInt64-i64Value) {
+				return 0, false, fmt.Errorf("at %s: sum overflow", row.seg.String())
+			}
+			result += i64Value
+		case !found, a.kind == "min" && i64Value < result, a.kind == "max" && i64Value > result:
+			result = i64Value
+		}
+		found = true
+	}
+	return result, !found && (a.kind == "min" || a.kind == "max"), nil
+}
+
+func (a *Aggregate) rowValue(row reflect.Value) (int64, error) {
+	var value any
+	var err error
+	if a.field == "" {
+		value, err = elemValue(row)
+	} else {
+		value, err = rowField(row, a.field)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return intValue(value)
+}
+
+// 整数转为 int64，自定义的 type MyInt int 这些按底层类型
+func intValue(value any) (int64, error) {
+	normalized, ok := fieldexpr.Normalize(value)
+	i64Value, isInt := normalized.(int64)
+	if !ok || !isInt {
+		return 0, fmt.Errorf("value %v is not an int", value)
+	}
+	return i64Value, nil
+}
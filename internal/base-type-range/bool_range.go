@@ -54,5 +54,8 @@ func (lr *BoolRange) Check(value any) bool {
 }
 
 func (lr *BoolRange) ToString() string {
-	return ""
+	if lr.noRange {
+		return "bool"
+	}
+	return "bool=" + lr.originalStr
 }
@@ -0,0 +1,294 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+组合 checker，把已有的 checker 组合成新的规则，写不出来的规则也可以直接用 Go 函数
+
+ 1. AndValueRangerChecker  - 每一个都要通过，如 int[1,100] 并且是 itemCfg.Id
+ 2. OrValueRangerChecker   - 有一个通过就行，如 enum A 或者 0、ref heroCfg.Id 或者 ref npcCfg.Id
+ 3. NotValueRangerChecker  - 不能通过，如不能是保留的 Id
+ 4. AnyValueRangerChecker  - 什么值都通过，用来给 map 的 key、value 这些必须有 checker 的地方占位
+ 5. FuncValueRangerChecker - Go 函数写的规则，返回 error 表示不通过
+
+不通过时：
+and 的每一个不通过的 checker 都按自己的路径记下来，和结构体的字段一样
+or 的所有分支都不通过时记一条，带上每一个分支不通过的原因，如：
+heroCfg[2].Quality: value 9 match none of or(enum(heroCfgQuality), int[0,0]): [0] value 9 (int) not match enum(heroCfgQuality); [1] value 9 (int) not match int[0,0]
+
+用 Go 函数写规则的地方有几个，回调的样子按用途分：
+ 1. FuncValueRangerChecker - 检测一个值，返回 error，值的规则五花八门，原因要让函数自己说
+ 2. FuncConstraint、CountFuncAggregate - 判断一行，返回 bool，它们是条件，
+    不通过时的信息由规则自己拼（约束带上涉及的字段的值，count 带上行数），函数不用给原因
+ 3. 泛型的 NewFuncChecker - 返回 bool，泛型的 checker 只有通过、不通过，用在不要报告、只求快的地方，
+    要报告中有原因时用 FuncValueRangerChecker
+*/
+
+// 没有 checker 的 and、or，再通过 Add 一个个加
+// 一个都没加时 and 什么都通过、or 什么都不通过，都不是想要的，第一次 Check、Compile 时 panic
+func AndValueRangerChecker() *AndRange {
+	return &AndRange{}
+}
+
+func OrValueRangerChecker() *OrRange {
+	return &OrRange{}
+}
+
+func (ar *AndRange) Add(checker baseChecker) *AndRange {
+	ar.checkers = addCombineChecker("and", ar.checkers, checker)
+	return ar
+}
+
+func (or *OrRange) Add(checker baseChecker) *OrRange {
+	or.checkers = addCombineChecker("or", or.checkers, checker)
+	return or
+}
+
+func NotValueRangerChecker(checker baseChecker) *NotRange {
+	if checker == nil {
+		panic("NotValueRangerChecker checker is nil")
+	}
+	return &NotRange{checker: checker}
+}
+
+func AnyValueRangerChecker() *AnyRange {
+	return &AnyRange{}
+}
+
+// desc 用于报告，如 "even"；fn 返回的 error 是不通过的原因
+func FuncValueRangerChecker(desc string, fn func(value any) error) *FuncRange {
+	if fn == nil {
+		panic("FuncValueRangerChecker fn is nil, desc: " + desc)
+	}
+	return &FuncRange{desc: desc, fn: fn}
+}
+
+func addCombineChecker(name string, checkers []baseChecker, checker baseChecker) []baseChecker {
+	if checker == nil {
+		panic(fmt.Sprintf("%s range checker %d is nil", name, len(checkers)))
+	}
+	return append(checkers, checker)
+}
+
+func mustHaveCheckers(name string, checkers []baseChecker) {
+	if len(checkers) == 0 {
+		panic(name + " range has no checker, add checkers before check")
+	}
+}
+
+func describeAll(name string, checkers []baseChecker) string {
+	strs := make([]string, 0, len(checkers))
+	for _, checker := range checkers {
+		strs = append(strs, describe(checker))
+	}
+	return name + "(" + strings.Join(strs, ", ") + ")"
+}
+
+type AndRange struct {
+	checkers []baseChecker
+}
+
+func (ar *AndRange) Check(value any) bool {
+	return ar.CheckWithReport(value, nil)
+}
+
+func (ar *AndRange) CheckWithReport(value any, report *Report) bool {
+	mustHaveCheckers("and", ar.checkers)
+	return checkAnd(len(ar.checkers), func(i int, report *Report) bool {
+		return CheckWithReport(ar.checkers[i], value, report)
+	}, report)
+}
+
+func (ar *AndRange) ToString() string {
+	return describeAll("and", ar.checkers)
+}
+
+// 没有报告时遇到不通过的就返回，有报告时检测完所有的 checker
+func checkAnd(n int, check func(i int, report *Report) bool, report *Report) bool {
+	pass := true
+	for i := 0; i < n; i++ {
+		if check(i, report) {
+			continue
+		}
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+type OrRange struct {
+	checkers []baseChecker
+}
+
+func (or *OrRange) Check(value any) bool {
+	return or.CheckWithReport(value, nil)
+}
+
+func (or *OrRange) CheckWithReport(value any, report *Report) bool {
+	mustHaveCheckers("or", or.checkers)
+	return checkOr(or.ToString, value, len(or.checkers), func(i int, report *Report) bool {
+		return CheckWithReport(or.checkers[i], value, report)
+	}, report)
+}
+
+func (or *OrRange) ToString() string {
+	return describeAll("or", or.checkers)
+}
+
+// 每一个分支用单独的报告检测，分支中路径是相对这个值的，都不通过时把每一个分支的原因合成一条
+// desc 只在不通过的时候才取
+func checkOr(desc func() string, value any, n int, check func(i int, report *Report) bool, report *Report) bool {
+	if report == nil {
+		for i := 0; i < n; i++ {
+			if check(i, nil) {
+				return true
+			}
+		}
+		Debugf("value %v match none of %s\n", value, desc())
+		return false
+	}
+
+	reasons := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		branch := &Report{}
+		if check(i, branch) {
+			return true
+		}
+		msgs := make([]string, 0, len(branch.Violations))
+		for _, v := range branch.Violations {
+			if v.Path != "" {
+				msgs = append(msgs, v.Path+": "+v.Msg)
+			} else {
+				msgs = append(msgs, v.Msg)
+			}
+		}
+		reasons = append(reasons, fmt.Sprintf("[%d] %s", i, strings.Join(msgs, ", ")))
+	}
+	msg := fmt.Sprintf("value %v match none of %s: %s", value, desc(), strings.Join(reasons, "; "))
+	Debugf("%s\n", msg)
+	report.Failf("%s", msg)
+	return false
+}
+
+type NotRange struct {
+	checker baseChecker
+}
+
+func (nr *NotRange) Check(value any) bool {
+	return nr.CheckWithReport(value, nil)
+}
+
+// 里面的 checker 不通过时的诊断信息不要，只在它通过的时候记一条
+func (nr *NotRange) CheckWithReport(value any, report *Report) bool {
+	if !nr.checker.Check(value) {
+		return true
+	}
+	failNot(report, value, nr.checker)
+	return false
+}
+
+func (nr *NotRange) ToString() string {
+	return "not(" + describe(nr.checker) + ")"
+}
+
+func failNot(report *Report, value any, checker baseChecker) {
+	Debugf("value %v (%T) should not match %s\n", value, value, describe(checker))
+	report.Failf("value %v (%T) should not match %s", value, value, describe(checker))
+}
+
+type AnyRange struct{}
+
+func (ar *AnyRange) Check(value any) bool {
+	return true
+}
+
+func (ar *AnyRange) ToString() string {
+	return "any"
+}
+
+type FuncRange struct {
+	desc string
+	fn   func(value any) error
+}
+
+func (fr *FuncRange) Check(value any) bool {
+	return fr.CheckWithReport(value, nil)
+}
+
+func (fr *FuncRange) CheckWithReport(value any, report *Report) bool {
+	err := fr.fn(value)
+	if err == nil {
+		return true
+	}
+	Debugf("%s: %v\n", fr.ToString(), err)
+	report.Failf("%s: %v", fr.ToString(), err)
+	return false
+}
+
+// 如 func(even)
+func (fr *FuncRange) ToString() string {
+	return "func(" + fr.desc + ")"
+}
+
+// 编译：每一个分支编译到同一个类型上
+func (ar *AndRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	mustHaveCheckers("and", ar.checkers)
+	return &compiledAndRange{checkers: compileAll(ar.checkers, valueType)}, true
+}
+
+func (or *OrRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	mustHaveCheckers("or", or.checkers)
+	return &compiledOrRange{desc: or.ToString, checkers: compileAll(or.checkers, valueType)}, true
+}
+
+func (nr *NotRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	return &compiledNotRange{original: nr.checker, checker: CompileChecker(nr.checker, valueType)}, true
+}
+
+func compileAll(checkers []baseChecker, valueType reflect.Type) []CompiledChecker {
+	compiled := make([]CompiledChecker, 0, len(checkers))
+	for _, checker := range checkers {
+		compiled = append(compiled, CompileChecker(checker, valueType))
+	}
+	return compiled
+}
+
+type compiledAndRange struct {
+	checkers []CompiledChecker
+}
+
+func (c *compiledAndRange) CheckValue(value reflect.Value, report *Report) bool {
+	return checkAnd(len(c.checkers), func(i int, report *Report) bool {
+		return c.checkers[i].CheckValue(value, report)
+	}, report)
+}
+
+type compiledOrRange struct {
+	desc     func() string
+	checkers []CompiledChecker
+}
+
+func (c *compiledOrRange) CheckValue(value reflect.Value, report *Report) bool {
+	return checkOr(c.desc, value, len(c.checkers), func(i int, report *Report) bool {
+		return c.checkers[i].CheckValue(value, report)
+	}, report)
+}
+
+type compiledNotRange struct {
+	original baseChecker // 用于报告
+	checker  CompiledChecker
+}
+
+func (c *compiledNotRange) CheckValue(value reflect.Value, report *Report) bool {
+	if !c.checker.CheckValue(value, nil) {
+		return true
+	}
+	failNot(report, value.Interface(), c.original)
+	return false
+}
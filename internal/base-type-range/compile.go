@@ -0,0 +1,422 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// 绑定到具体类型上的 checker
+// 直接在 reflect.Value 上检测，不用每个值都做类型判断、FieldByName 查找、Interface() 装箱
+type CompiledChecker interface {
+	CheckValue(value reflect.Value, report *Report) bool
+}
+
+// 可以针对具体类型编译的 checker
+// 返回 false 表示这个类型没有专门的检测路径，会退回到普通的 Check
+type Compilable interface {
+	Compile(valueType reflect.Type) (CompiledChecker, bool)
+}
+
+// 把 checker 编译到 valueType 上，不能编译的部分退回到普通的 Check
+func CompileChecker(checker baseChecker, valueType reflect.Type) CompiledChecker {
+	if c, ok := checker.(Compilable); ok {
+		if compiled, ok := c.Compile(valueType); ok {
+			return compiled
+		}
+	}
+	return &fallbackChecker{checker: checker}
+}
+
+// 退回到普通 Check 的编译结果
+type fallbackChecker struct {
+	checker baseChecker
+}
+
+func (fc *fallbackChecker) CheckValue(value reflect.Value, report *Report) bool {
+	return CheckWithReport(fc.checker, value.Interface(), report)
+}
+
+// 编译结果的检测失败时，记一条和普通 Check 一样格式的信息
+func FailValue(report *Report, value reflect.Value, checker any) {
+	if report == nil {
+		return
+	}
+	report.Failf("value %v (%s) not match %s", value, value.Type().String(), describe(checker))
+}
+
+// 是否是内置的类型，如 int、string
+// 普通的 Check 是用 type switch 判断的，自定义的 type MyInt int 是通不过的，编译的时候也要保持一致
+func IsPredeclared(valueType reflect.Type) bool {
+	return valueType.PkgPath() == "" && valueType.Name() == valueType.Kind().String()
+}
+
+func CompileValueRangerChecker(checker baseChecker, valueType reflect.Type) *CompiledRange {
+	if valueType == nil {
+		panic("CompileValueRangerChecker value type is nil")
+	}
+	return &CompiledRange{
+		checker:   checker,
+		valueType: valueType,
+		compiled:  CompileChecker(checker, valueType),
+	}
+}
+
+// 编译好的 checker，值的类型和编译时的类型一致时走编译好的路径，否则退回到原来的 checker
+type CompiledRange struct {
+	checker   baseChecker
+	valueType reflect.Type
+	compiled  CompiledChecker
+}
+
+func (cr *CompiledRange) Check(value any) bool {
+	return cr.CheckWithReport(value, nil)
+}
+
+func (cr *CompiledRange) CheckWithReport(value any, report *Report) bool {
+	if reflect.TypeOf(value) != cr.valueType {
+		return CheckWithReport(cr.checker, value, report)
+	}
+	return cr.compiled.CheckValue(reflect.ValueOf(value), report)
+}
+
+func (cr *CompiledRange) ToString() string {
+	return fmt.Sprintf("compiled(%s)", describe(cr.checker))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+/// 各个基础类型的编译
+
+func (ir *IntRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if !IsPredeclared(valueType) {
+		return nil, false
+	}
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &compiledIntRange{ir: ir}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if valueType.Kind() == reflect.Uintptr { // Check 不支持 uintptr
+			return nil, false
+		}
+		return &compiledUintRange{ir: ir}, true
+	default:
+		return nil, false
+	}
+}
+
+type compiledIntRange struct {
+	ir *IntRange
+}
+
+func (c *compiledIntRange) CheckValue(value reflect.Value, report *Report) bool {
+	if c.ir.CheckInt64(value.Int()) {
+		return true
+	}
+	FailValue(report, value, c.ir)
+	return false
+}
+
+type compiledUintRange struct {
+	ir *IntRange
+}
+
+func (c *compiledUintRange) CheckValue(value reflect.Value, report *Report) bool {
+	if c.ir.CheckUint64(value.Uint()) {
+		return true
+	}
+	FailValue(report, value, c.ir)
+	return false
+}
+
+func (sr *StringRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if valueType.Kind() != reflect.String || !IsPredeclared(valueType) {
+		return nil, false
+	}
+	return &compiledStringRange{}, true
+}
+
+type compiledStringRange struct{}
+
+func (c *compiledStringRange) CheckValue(value reflect.Value, report *Report) bool {
+	return true // 类型在编译的时候就确定是 string 了
+}
+
+func (lr *BoolRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if valueType.Kind() != reflect.Bool || !IsPredeclared(valueType) {
+		return nil, false
+	}
+	return &compiledBoolRange{br: lr}, true
+}
+
+type compiledBoolRange struct {
+	br *BoolRange
+}
+
+func (c *compiledBoolRange) CheckValue(value reflect.Value, report *Report) bool {
+	if c.br.Check(value.Bool()) {
+		return true
+	}
+	FailValue(report, value, c.br)
+	return false
+}
+
+func (lr *ListRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if valueType.Kind() != reflect.Array && valueType.Kind() != reflect.Slice {
+		return nil, false
+	}
+	return &compiledListRange{
+		elemChecker: CompileChecker(lr.fieldChecker, valueType.Elem()),
+		uniques:     lr.uniques,
+		orders:      lr.orders,
+		aggregates:  lr.aggregates,
+		sequences:   lr.sequences,
+		lenRange:    lr.lenRange,
+	}, true
+}
+
+type compiledListRange struct {
+	elemChecker CompiledChecker
+	uniques     []uniqueRule
+	orders      []orderRule
+	aggregates  []*Aggregate
+	sequences   []sequenceRule
+	lenRange    *IntRange
+}
+
+func (c *compiledListRange) CheckValue(value reflect.Value, report *Report) bool {
+	pass := true
+	if !checkLen(c.lenRange, value, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	length := value.Len()
+	for i := 0; i < length; i++ {
+		report.PushIndex(i)
+		ok := c.elemChecker.CheckValue(value.Index(i), report)
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+	if len(c.uniques) > 0 && !checkUniques(c.uniques, listRows(value), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(c.orders) > 0 && !checkOrders(c.orders, listRows(value), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(c.aggregates) > 0 && !checkAggregates(c.aggregates, listRows(value), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(c.sequences) > 0 && !checkSequences(c.sequences, listRows(value), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+func (mr *MapRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if valueType.Kind() != reflect.Map {
+		return nil, false
+	}
+	return &compiledMapRange{
+		keyChecker:  CompileChecker(mr.keyChecker, valueType.Key()),
+		elemChecker: CompileChecker(mr.fieldChecker, valueType.Elem()),
+		uniques:     mr.uniques,
+		aggregates:  mr.aggregates,
+		sequences:   mr.sequences,
+		lenRange:    mr.lenRange,
+		keySets:     mr.keySets,
+	}, true
+}
+
+type compiledMapRange struct {
+	keyChecker  CompiledChecker
+	elemChecker CompiledChecker
+	uniques     []uniqueRule
+	aggregates  []*Aggregate
+	sequences   []sequenceRule
+	lenRange    *IntRange
+	keySets     []keySetRule
+}
+
+func (c *compiledMapRange) CheckValue(value reflect.Value, report *Report) bool {
+	if report != nil { // 要诊断信息的时候，按排好序的 key 来检测
+		pass := checkLen(c.lenRange, value, report)
+		if len(c.keySets) > 0 && !checkKeySets(c.keySets, value, report) {
+			pass = false
+		}
+		for _, entry := range mapEntries(value, true) {
+			report.PushKey(entry.key.Interface())
+			ok := c.keyChecker.CheckValue(entry.key, report) && c.elemChecker.CheckValue(entry.value, report)
+			report.Pop()
+			pass = pass && ok
+		}
+		if len(c.uniques) > 0 && !checkUniques(c.uniques, mapRows(value, true), report) {
+			pass = false
+		}
+		if len(c.aggregates) > 0 && !checkAggregates(c.aggregates, mapRows(value, true), report) {
+			pass = false
+		}
+		if len(c.sequences) > 0 && !checkSequences(c.sequences, mapRows(value, true), report) {
+			pass = false
+		}
+		return pass
+	}
+
+	if !checkLen(c.lenRange, value, nil) {
+		return false
+	}
+	if len(c.keySets) > 0 && !checkKeySets(c.keySets, value, nil) {
+		return false
+	}
+	iter := value.MapRange()
+	for iter.Next() {
+		if !c.keyChecker.CheckValue(iter.Key(), nil) || !c.elemChecker.CheckValue(iter.Value(), nil) {
+			return false
+		}
+	}
+	if len(c.uniques) > 0 && !checkUniques(c.uniques, mapRows(value, false), nil) {
+		return false
+	}
+	if len(c.aggregates) > 0 && !checkAggregates(c.aggregates, mapRows(value, false), nil) {
+		return false
+	}
+	return len(c.sequences) == 0 || checkSequences(c.sequences, mapRows(value, false), nil)
+}
+
+func (sr *StructRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	if valueType.Kind() == reflect.Ptr { // 和 Check 一样，解开所有层的指针
+		elemType := valueType.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		elemCompiled, ok := sr.Compile(elemType)
+		if !ok {
+			return nil, false
+		}
+		return &compiledPtrRange{elemChecker: elemCompiled}, true
+	}
+	if valueType.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	fields := make([]compiledStructField, 0, len(sr.fieldNames))
+	for _, fieldName := range sr.fieldNames {
+		field, ok := valueType.FieldByName(fieldName)
+		if !ok { // 少字段了，走普通的 Check，让它去报告
+			return nil, false
+		}
+		unexported := !field.IsExported()
+		if unexported && !IsBasicKind(field.Type.Kind()) { // 编译的时候就知道类型了，直接拒绝
+			panic(fmt.Sprintf("struct range checker field: %s is unexported in %s, type %s can not be read, only basic types can be checked", fieldName, valueType.String(), field.Type.String()))
+		}
+		fields = append(fields, compiledStructField{
+			name:       fieldName,
+			index:      field.Index,
+			unexported: unexported,
+			checker:    CompileChecker(sr.mapChecker[fieldName], field.Type),
+		})
+	}
+	compiled := &compiledStructRange{fields: fields, sr: sr}
+	if sr.strict != StrictOff {
+		compiled.unchecked = sr.uncheckedFields(valueType)
+	}
+	return compiled, true
+}
+
+type compiledStructField struct {
+	name       string
+	index      []int // 字段的下标，嵌入结构体的字段会有多层
+	unexported bool  // 未导出的字段，拷贝一份出来再检测，退回到普通 Check 的时候才不会 panic
+	checker    CompiledChecker
+}
+
+type compiledStructRange struct {
+	fields    []compiledStructField
+	sr        *StructRange
+	unchecked []string // 严格模式下，编译的类型中没有规则的字段
+}
+
+func (c *compiledStructRange) CheckValue(value reflect.Value, report *Report) bool {
+	pass := true
+	for i := range c.fields {
+		field := &c.fields[i]
+		var fieldValue reflect.Value
+		if len(field.index) == 1 {
+			fieldValue = value.Field(field.index[0])
+		} else {
+			var err error
+			if fieldValue, err = value.FieldByIndexErr(field.index); err != nil {
+				failNilEmbedded(report, field.name, value.Type())
+				if report == nil {
+					return false
+				}
+				pass = false
+				continue
+			}
+		}
+		if field.unexported {
+			fieldValue, _ = readableField(fieldValue)
+		}
+		report.PushField(field.name)
+		ok := field.checker.CheckValue(fieldValue, report)
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+	if len(c.sr.switches) > 0 && !c.sr.checkSwitches(value, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(c.sr.constraints) > 0 && !c.sr.checkConstraints(value, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(c.unchecked) > 0 && !c.sr.checkUnchecked(c.unchecked, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+type compiledPtrRange struct {
+	elemChecker CompiledChecker
+}
+
+func (c *compiledPtrRange) CheckValue(value reflect.Value, report *Report) bool {
+	valueType := value.Type()
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			Debugf("value is nil ptr, type: %s\n", valueType.String())
+			report.Failf("value is nil ptr, type: %s", valueType.String())
+			return false
+		}
+		value = value.Elem()
+	}
+	return c.elemChecker.CheckValue(value, report)
+}
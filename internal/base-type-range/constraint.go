@@ -0,0 +1,169 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+)
+
+/*
+结构体级别的约束，拿到整行去检测，字段的 checker 只能看到自己的值，像 MinLevel <= MaxLevel 这样的规则写不出来
+
+ 1. ExprConstraint - 表达式，语法见 internal/field-expr，如 if Open then Quality >= 3
+ 2. FuncConstraint - Go 函数，表达式写不出来的时候用
+
+不通过时记在涉及的第一个字段上，信息中带上所有涉及的字段的值，如：
+heroCfg[3].MinLevel: constraint MinLevel <= MaxLevel not match: MinLevel=10, MaxLevel=5
+.
+*/
+
+type Constraint struct {
+	desc   string
+	fields []string // 涉及的字段，嵌套结构体的字段如 Tag.Free
+	check  func(row reflect.Value) (bool, error)
+}
+
+// 表达式不合法时 panic
+func ExprConstraint(expr string) *Constraint {
+	e, err := fieldexpr.Parse(expr)
+	if err != nil {
+		panic(fmt.Sprintf("constraint expr illegal: %s, err: %v", expr, err))
+	}
+	return &Constraint{
+		desc:   expr,
+		fields: e.Fields(),
+		check: func(row reflect.Value) (bool, error) {
+			return e.Eval(func(name string) (any, error) {
+				return rowField(row, name)
+			})
+		},
+	}
+}
+
+// row 是结构体的值（指针已经解开了）或 map[string]any 这样的值
+// fields 是涉及的字段，只用于报告
+func FuncConstraint(desc string, fn func(row any) bool, fields ...string) *Constraint {
+	if fn == nil {
+		panic("FuncConstraint fn is nil")
+	}
+	return &Constraint{
+		desc:   desc,
+		fields: fields,
+		check: func(row reflect.Value) (bool, error) {
+			if !row.CanInterface() {
+				return false, fmt.Errorf("row %s can not be read", row.Type().String())
+			}
+			return fn(row.Interface()), nil
+		},
+	}
+}
+
+func (c *Constraint) ToString() string {
+	return c.desc
+}
+
+func (sr *StructRange) AddConstraint(constraint *Constraint) *StructRange {
+	if constraint == nil {
+		panic("struct range constraint is nil")
+	}
+	sr.constraints = append(sr.constraints, constraint)
+	return sr
+}
+
+// 检测所有约束，row 是结构体或 map 的值
+func (sr *StructRange) checkConstraints(row reflect.Value, report *Report) bool {
+	pass := true
+	for _, c := range sr.constraints {
+		ok, err := c.check(row)
+		if ok {
+			continue
+		}
+
+		var msg string
+		if err != nil {
+			msg = fmt.Sprintf("constraint %s error: %v", c.desc, err)
+		} else {
+			msg = fmt.Sprintf("constraint %s not match", c.desc)
+			if values := c.fieldValues(row); values != "" {
+				msg += ": " + values
+			}
+		}
+		Debugf("%s\n", msg)
+		if report == nil {
+			return false
+		}
+		depth := 0
+		if len(c.fields) > 0 {
+			for _, name := range strings.Split(c.fields[0], ".") {
+				report.PushField(name)
+				depth++
+			}
+		}
+		report.Failf("%s", msg)
+		for ; depth > 0; depth-- {
+			report.Pop()
+		}
+		pass = false
+	}
+	return pass
+}
+
+// 涉及的字段的值，如 MinLevel=10, MaxLevel=5
+func (c *Constraint) fieldValues(row reflect.Value) string {
+	parts := make([]string, 0, len(c.fields))
+	for _, name := range c.fields {
+		value, err := rowField(row, name)
+		if err != nil {
+			parts = append(parts, name+"=?")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", name, value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 按字段名取一行中的值，嵌套的字段用 . 连接，指针、interface 会解开
+func rowField(row reflect.Value, name string) (any, error) {
+	value := row
+	for _, part := range strings.Split(name, ".") {
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return nil, fmt.Errorf("field %s is nil", name)
+			}
+			value = value.Elem()
+		}
+		switch {
+		case value.Kind() == reflect.Struct:
+			field, ok := value.Type().FieldByName(part)
+			if !ok {
+				return nil, fmt.Errorf("value has no field: %s", name)
+			}
+			fieldValue, err := value.FieldByIndexErr(field.Index)
+			if err != nil {
+				return nil, fmt.Errorf("field %s is in nil embedded ptr", name)
+			}
+			value = fieldValue
+		case isStringKeyMap(value.Type()):
+			mapValue := value.MapIndex(reflect.ValueOf(part).Convert(value.Type().Key()))
+			if !mapValue.IsValid() {
+				return nil, fmt.Errorf("value has no key: %s", name)
+			}
+			value = mapValue
+		default:
+			return nil, fmt.Errorf("field %s: %s is not struct or map", name, value.Type().String())
+		}
+	}
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, fmt.Errorf("field %s is nil", name)
+		}
+		value = value.Elem()
+	}
+	readable, ok := readableField(value)
+	if !ok {
+		return nil, fmt.Errorf("field %s is unexported, type %s can not be read", name, value.Type().String())
+	}
+	return readable.Interface(), nil
+}
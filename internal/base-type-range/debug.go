@@ -0,0 +1,35 @@
+package basetyperange
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+/*
+检测过程中的调试信息，如不通过的值、规则不存在，默认输出到 os.Stdout
+命令行这种只要报告的可以 SetDebugOutput(io.Discard) 关掉，不用去替换 os.Stdout
+.
+*/
+
+type debugWriter struct {
+	w io.Writer
+}
+
+var debugOutput atomic.Pointer[debugWriter]
+
+func SetDebugOutput(w io.Writer) {
+	if w == nil {
+		w = os.Stdout
+	}
+	debugOutput.Store(&debugWriter{w: w})
+}
+
+func Debugf(format string, args ...any) {
+	w := io.Writer(os.Stdout)
+	if dw := debugOutput.Load(); dw != nil {
+		w = dw.w
+	}
+	fmt.Fprintf(w, format, args...)
+}
@@ -61,7 +61,7 @@ func IntValueRangerChecker(rangeStr string) *IntRange {
 	maxStr := matches[3]
 	rightBracket := matches[4]
 
-	// fmt.Printf("originalStr: %s, leftBracket: %s, minStr: %s, maxStr: %s, rightBracket: %s\n", originalStr, leftBracket, minStr, maxStr, rightBracket)
+	// Debugf("originalStr: %s, leftBracket: %s, minStr: %s, maxStr: %s, rightBracket: %s\n", originalStr, leftBracket, minStr, maxStr, rightBracket)
 
 	// 判断是否包含最小值（[ 表示包含，( 表示不包含）
 	inclusiveMin := leftBracket == "["
@@ -119,7 +119,7 @@ func (ir *IntRange) Check(value any) bool {
 		case uint, uint8, uint16, uint32, uint64:
 			return true
 		default:
-			fmt.Printf("IntRange check value: [%+v] not int type", value)
+			Debugf("IntRange check value: [%+v] not int type", value)
 			return false
 		}
 	}
@@ -138,7 +138,7 @@ func (ir *IntRange) Check(value any) bool {
 		i64Value = v
 	case uint:
 		if v > math.MaxInt64 {
-			fmt.Printf("IntRange check value: uint[%d] over int64 max", value)
+			Debugf("IntRange check value: uint[%d] over int64 max", value)
 			return false
 		}
 		i64Value = int64(v)
@@ -150,15 +150,24 @@ func (ir *IntRange) Check(value any) bool {
 		i64Value = int64(v)
 	case uint64:
 		if v > math.MaxInt64 {
-			fmt.Printf("IntRange check value: uint64[%d] over int64 max", value)
+			Debugf("IntRange check value: uint64[%d] over int64 max", value)
 			return false
 		}
 		i64Value = int64(v)
 	default:
-		fmt.Printf("IntRange check value: [%+v] not int type", value)
+		Debugf("IntRange check value: [%+v] not int type", value)
 		return false
 	}
 
+	return ir.CheckInt64(i64Value)
+}
+
+// 已经是 int64 的值，直接检测范围
+func (ir *IntRange) CheckInt64(i64Value int64) bool {
+	if ir.noRange {
+		return true
+	}
+
 	if ir.inclusiveMin {
 		if i64Value < ir.min {
 			return false
@@ -183,6 +192,18 @@ func (ir *IntRange) Check(value any) bool {
 	return true
 }
 
+// 已经是 uint64 的值，直接检测范围
+func (ir *IntRange) CheckUint64(u64Value uint64) bool {
+	if ir.noRange {
+		return true
+	}
+	if u64Value > math.MaxInt64 {
+		Debugf("IntRange check value: uint64[%d] over int64 max", u64Value)
+		return false
+	}
+	return ir.CheckInt64(int64(u64Value))
+}
+
 func (ir *IntRange) ToString() string {
-	return ""
+	return "int" + ir.originalStr
 }
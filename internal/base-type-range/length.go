@@ -0,0 +1,43 @@
+package basetyperange
+
+import (
+	"reflect"
+)
+
+// 列表、map 的长度范围，和 IntRange 一样的区间写法：
+//  1. [1,-]  - 不能为空
+//  2. [3,3]  - 正好 3 个
+//  3. [0,10] - 最多 10 个
+func (lr *ListRange) WithLen(rangeStr string) *ListRange {
+	lr.lenRange = newLenRange(rangeStr)
+	return lr
+}
+
+func (mr *MapRange) WithLen(rangeStr string) *MapRange {
+	mr.lenRange = newLenRange(rangeStr)
+	return mr
+}
+
+func newLenRange(rangeStr string) *IntRange {
+	if rangeStr == "" {
+		panic("len range is empty")
+	}
+	return IntValueRangerChecker(rangeStr)
+}
+
+// 长度不在范围内时记在容器本身上
+func checkLen(lenRange *IntRange, value reflect.Value, report *Report) bool {
+	if lenRange == nil || lenRange.Check(value.Len()) {
+		return true
+	}
+	Debugf("length %d not match len%s\n", value.Len(), lenRange.originalStr)
+	report.Failf("length %d not match len%s", value.Len(), lenRange.originalStr)
+	return false
+}
+
+func lenString(lenRange *IntRange) string {
+	if lenRange == nil {
+		return ""
+	}
+	return lenRange.originalStr
+}
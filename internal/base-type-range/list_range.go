@@ -12,27 +12,79 @@ func ListValueRangerChecker(fieldChecker baseChecker) *ListRange {
 
 type ListRange struct {
 	fieldChecker baseChecker
+	uniques      []uniqueRule   // 元素不能重复的规则，元素都检测完之后再检测
+	orders       []orderRule    // 元素要按顺序排好的规则，在 uniques 之后检测
+	aggregates   []*Aggregate   // 所有元素汇总起来的值的规则
+	sequences    []sequenceRule // 值要连续的规则，最后检测
+	lenRange     *IntRange      // 长度范围，nil 时不限制
 }
 
 func (lr *ListRange) Check(value any) bool {
+	return lr.CheckWithReport(value, nil)
+}
+
+func (lr *ListRange) CheckWithReport(value any, report *Report) bool {
+	if value == nil {
+		report.Failf("value is nil")
+		return false
+	}
 	valueType := reflect.TypeOf(value)
 	if valueType.Kind() != reflect.Array && valueType.Kind() != reflect.Slice { // 必须是数组的类型
+		report.Failf("value no list, is: %s", valueType.Kind().String())
 		return false
 	}
 
-	// 遍历数组的每个元素进行检测
+	// 遍历数组的每个元素进行检测，需要诊断信息的时候，不在第一个不通过的地方停下来，把所有不通过的都记下来
+	pass := true
 	valueValue := reflect.ValueOf(value)
+	if !checkLen(lr.lenRange, valueValue, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
 	length := valueValue.Len()
 	for i := 0; i < length; i++ {
 		elemValue := valueValue.Index(i).Interface()
-		if !lr.fieldChecker.Check(elemValue) {
+		report.PushIndex(i)
+		ok := CheckWithReport(lr.fieldChecker, elemValue, report)
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+
+	if len(lr.uniques) > 0 && !checkUniques(lr.uniques, listRows(valueValue), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(lr.orders) > 0 && !checkOrders(lr.orders, listRows(valueValue), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(lr.aggregates) > 0 && !checkAggregates(lr.aggregates, listRows(valueValue), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(lr.sequences) > 0 && !checkSequences(lr.sequences, listRows(valueValue), report) {
+		if report == nil {
 			return false
 		}
+		pass = false
 	}
 
-	return true
+	return pass
 }
 
 func (lr *ListRange) ToString() string {
-	return ""
+	return "list" + lenString(lr.lenRange) + "<" + describe(lr.fieldChecker) + ">"
 }
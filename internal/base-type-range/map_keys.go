@@ -0,0 +1,118 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+map 中要有的 key，keyChecker 只能一个个地检测 key，像“Attrs 中要有 hp 和 mp”这样的规则写不出来
+
+ 1. AddRequiredKeys - 这些 key 都要有，别的 key 不管
+ 2. AddKeySet       - key 的集合，每一个都要有；closed 时只能有这些 key，如枚举中的每一个值都要配置，不能多也不能少
+
+key 按 fieldexpr.Normalize 转换后比较，int 的 1 和 uint32 的 1 是同一个，规则文件中的 "1" 和 1 也是同一个
+不通过时记在 map 本身上，如：
+heroCfg[0].Attrs: keys enum(heroCfgAttr) missing: 3, extra: 9
+.
+*/
+
+type keySetRule struct {
+	desc   string                // 如 required(hp,mp)、enum(heroCfgAttr)
+	keys   func() ([]any, error) // 要有的 key，检测的时候再取
+	closed bool                  // 只能有这些 key
+}
+
+// 这些 key 都要有，keys 为空时 panic
+func (mr *MapRange) AddRequiredKeys(keys ...any) *MapRange {
+	if len(keys) == 0 {
+		panic("map required keys is empty")
+	}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, mapKeyString(key))
+	}
+	return mr.AddKeySet("required("+strings.Join(names, ",")+")", func() ([]any, error) {
+		return keys, nil
+	}, false)
+}
+
+// keys 中的每一个都要有，closed 时只能有这些 key；keys 在检测的时候才调用，如枚举可以在创建 checker 之后再变
+func (mr *MapRange) AddKeySet(desc string, keys func() ([]any, error), closed bool) *MapRange {
+	if keys == nil {
+		panic("map key set keys is nil")
+	}
+	mr.keySets = append(mr.keySets, keySetRule{desc: desc, keys: keys, closed: closed})
+	return mr
+}
+
+func mapKeyString(key any) string {
+	if s, ok := switchKey(key); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+func checkKeySets(keySets []keySetRule, mapValue reflect.Value, report *Report) bool {
+	pass := true
+	for _, k := range keySets {
+		if k.check(mapValue, report) {
+			continue
+		}
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+func (k keySetRule) check(mapValue reflect.Value, report *Report) bool {
+	want, err := k.keys()
+	if err != nil {
+		msg := fmt.Sprintf("keys %s error: %v", k.desc, err)
+		Debugf("%s\n", msg)
+		report.Failf("%s", msg)
+		return false
+	}
+
+	wantSet := make(map[string]struct{}, len(want))
+	for _, key := range want {
+		wantSet[mapKeyString(key)] = struct{}{}
+	}
+	haveSet := make(map[string]struct{}, mapValue.Len())
+	var extra []string
+	for _, key := range mapKeys(mapValue, true) {
+		name := mapKeyString(key.Interface())
+		haveSet[name] = struct{}{}
+		if _, ok := wantSet[name]; !ok && k.closed {
+			extra = append(extra, name)
+		}
+	}
+	var missing []string
+	for _, key := range want {
+		name := mapKeyString(key)
+		if _, ok := haveSet[name]; !ok {
+			missing = append(missing, name)
+			haveSet[name] = struct{}{} // 重复的 key 只记一次
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true
+	}
+
+	msg := "keys " + k.desc
+	if len(missing) > 0 {
+		msg += " missing: " + strings.Join(missing, ", ")
+	}
+	if len(extra) > 0 {
+		if len(missing) > 0 {
+			msg += ","
+		}
+		msg += " extra: " + strings.Join(extra, ", ")
+	}
+	Debugf("%s\n", msg)
+	report.Failf("%s", msg)
+	return false
+}
@@ -3,6 +3,7 @@ package basetyperange
 import (
 	"fmt"
 	"reflect"
+	"sort"
 )
 
 func MapValueRangerChecker(keyChecker baseChecker, fieldChecker baseChecker) *MapRange {
@@ -15,41 +16,115 @@ func MapValueRangerChecker(keyChecker baseChecker, fieldChecker baseChecker) *Ma
 type MapRange struct {
 	keyChecker   baseChecker
 	fieldChecker baseChecker
+	uniques      []uniqueRule   // value 不能重复的规则，都检测完之后再检测
+	aggregates   []*Aggregate   // 所有 value 汇总起来的值的规则
+	sequences    []sequenceRule // key 或 value 中的字段要连续的规则，最后检测
+	lenRange     *IntRange      // 长度范围，nil 时不限制
+	keySets      []keySetRule   // 要有的 key，在长度之后检测
 }
 
 func (mr *MapRange) Check(value any) bool {
+	return mr.CheckWithReport(value, nil)
+}
+
+func (mr *MapRange) CheckWithReport(value any, report *Report) bool {
+	if value == nil {
+		Debugf("value is nil\n")
+		report.Failf("value is nil")
+		return false
+	}
 	valueType := reflect.TypeOf(value)
 	if valueType.Kind() != reflect.Map { // 必须是 map 类型
-		fmt.Printf("value no struct, is: %s\n", valueType.Kind().String())
+		Debugf("value no struct, is: %s\n", valueType.Kind().String())
+		report.Failf("value no map, is: %s", valueType.Kind().String())
 		return false
 	}
 
-	// 获得这个 map 的key 的类型
-	keyType := valueType.Key()
-	switch keyType.Kind() { // 只支持部分 key 类型，因为考虑到这个类型范围检测，主要是为了从 配置表、协议等场景中的数据范围检测使用，这些场景下的 map 的 key 的类型一般不会太复杂
-	case reflect.String:
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		// 支持的 key 类型
-	default:
-		fmt.Printf("map key type no support, is: %s\n", keyType.Kind().String())
-		return false
-	}
+	// key 的类型不做限制，map 的 key 都是可比较的，能不能检测交给 keyChecker
 
+	pass := true
 	valueValue := reflect.ValueOf(value)
-	for _, key := range valueValue.MapKeys() {
-		mapValue := valueValue.MapIndex(key).Interface()
-		if !mr.keyChecker.Check(key.Interface()) {
+	if !checkLen(mr.lenRange, valueValue, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	if len(mr.keySets) > 0 && !checkKeySets(mr.keySets, valueValue, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	for _, entry := range mapEntries(valueValue, report != nil) {
+		keyValue := entry.key.Interface()
+		mapValue := entry.value.Interface()
+		report.PushKey(keyValue)
+		ok := CheckWithReport(mr.keyChecker, keyValue, report) && CheckWithReport(mr.fieldChecker, mapValue, report)
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+
+	if len(mr.uniques) > 0 && !checkUniques(mr.uniques, mapRows(valueValue, report != nil), report) {
+		if report == nil {
 			return false
 		}
-		if !mr.fieldChecker.Check(mapValue) {
+		pass = false
+	}
+	if len(mr.aggregates) > 0 && !checkAggregates(mr.aggregates, mapRows(valueValue, report != nil), report) {
+		if report == nil {
 			return false
 		}
+		pass = false
+	}
+	if len(mr.sequences) > 0 && !checkSequences(mr.sequences, mapRows(valueValue, report != nil), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
 	}
 
-	return true
+	return pass
 }
 
 func (mt *MapRange) ToString() string {
-	return ""
+	return "map" + lenString(mt.lenRange) + "<" + describe(mt.keyChecker) + "," + describe(mt.fieldChecker) + ">"
+}
+
+// 获得 map 的所有 key，需要诊断信息的时候排个序，让报告的顺序是稳定的
+func mapKeys(mapValue reflect.Value, sorted bool) []reflect.Value {
+	keys := mapValue.MapKeys()
+	if sorted {
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+	}
+	return keys
+}
+
+// map 中的一对 key、value
+type mapEntry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+// 获得 map 的所有 key、value，排序同 mapKeys
+// 不能用 MapKeys 再 MapIndex 取值，float 的 NaN key 不等于自己，MapIndex 取不到
+func mapEntries(mapValue reflect.Value, sorted bool) []mapEntry {
+	entries := make([]mapEntry, 0, mapValue.Len())
+	iter := mapValue.MapRange()
+	for iter.Next() {
+		entries = append(entries, mapEntry{key: iter.Key(), value: iter.Value()})
+	}
+	if sorted {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return fmt.Sprint(entries[i].key.Interface()) < fmt.Sprint(entries[j].key.Interface())
+		})
+	}
+	return entries
 }
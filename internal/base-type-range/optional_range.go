@@ -0,0 +1,166 @@
+package basetyperange
+
+import (
+	"reflect"
+)
+
+/*
+可选、必填
+
+配置、协议中经常有可以不填的字段，用指针、slice、map 表示，不填的时候是 nil
+ 1. OptionalValueRangerChecker - 不填（nil）通过，填了的话用里面的 checker 检测
+ 2. RequiredValueRangerChecker - 不填（nil）不通过，填了的话用里面的 checker 检测
+
+不填指的是：nil、nil 指针、nil slice、nil map，空的 slice、map 是填了的，如 json 中的 []
+填了的指针会解开所有层的引用再交给里面的 checker，所以 *int 字段可以直接用 int 的 checker
+多层指针中任何一层是 nil 都算不填
+.
+*/
+
+func OptionalValueRangerChecker(checker baseChecker) *OptionalRange {
+	if checker == nil {
+		panic("OptionalValueRangerChecker checker is nil")
+	}
+	return &OptionalRange{checker: checker}
+}
+
+func RequiredValueRangerChecker(checker baseChecker) *RequiredRange {
+	if checker == nil {
+		panic("RequiredValueRangerChecker checker is nil")
+	}
+	return &RequiredRange{checker: checker}
+}
+
+type OptionalRange struct {
+	checker baseChecker
+}
+
+func (or *OptionalRange) Check(value any) bool {
+	return or.CheckWithReport(value, nil)
+}
+
+func (or *OptionalRange) CheckWithReport(value any, report *Report) bool {
+	if IsAbsent(value) {
+		return true
+	}
+	return CheckWithReport(or.checker, derefPresent(value), report)
+}
+
+func (or *OptionalRange) ToString() string {
+	return "optional(" + describe(or.checker) + ")"
+}
+
+type RequiredRange struct {
+	checker baseChecker
+}
+
+func (rr *RequiredRange) Check(value any) bool {
+	return rr.CheckWithReport(value, nil)
+}
+
+func (rr *RequiredRange) CheckWithReport(value any, report *Report) bool {
+	if IsAbsent(value) {
+		failAbsent(report, reflect.TypeOf(value))
+		return false
+	}
+	return CheckWithReport(rr.checker, derefPresent(value), report)
+}
+
+func (rr *RequiredRange) ToString() string {
+	return "required(" + describe(rr.checker) + ")"
+}
+
+// 值是否是不填的：nil、nil 指针、nil slice、nil map
+func IsAbsent(value any) bool {
+	if value == nil {
+		return true
+	}
+	valueValue := reflect.ValueOf(value)
+	for valueValue.Kind() == reflect.Ptr && !valueValue.IsNil() {
+		valueValue = valueValue.Elem()
+	}
+	switch valueValue.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return valueValue.IsNil()
+	default:
+		return false
+	}
+}
+
+// 填了的指针解开所有层的引用
+func derefPresent(value any) any {
+	valueValue := reflect.ValueOf(value)
+	if valueValue.Kind() != reflect.Ptr {
+		return value
+	}
+	for valueValue.Kind() == reflect.Ptr {
+		valueValue = valueValue.Elem()
+	}
+	return valueValue.Interface()
+}
+
+func failAbsent(report *Report, valueType reflect.Type) {
+	typeStr := "nil"
+	if valueType != nil {
+		typeStr = valueType.String()
+	}
+	Debugf("value is required, but is nil, type: %s\n", typeStr)
+	report.Failf("value is required, but is nil, type: %s", typeStr)
+}
+
+// 编译：指针在编译的时候就知道要解引用，里面的 checker 编译到指向的类型上
+func (or *OptionalRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	return compilePresence(or.checker, false, valueType)
+}
+
+func (rr *RequiredRange) Compile(valueType reflect.Type) (CompiledChecker, bool) {
+	return compilePresence(rr.checker, true, valueType)
+}
+
+func compilePresence(checker baseChecker, required bool, valueType reflect.Type) (CompiledChecker, bool) {
+	switch valueType.Kind() {
+	case reflect.Interface: // 具体类型要到检测的时候才知道
+		return nil, false
+	case reflect.Ptr:
+		elemType := valueType.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		return &compiledPresenceRange{
+			required: required,
+			deref:    true,
+			checker:  CompileChecker(checker, elemType),
+		}, true
+	default:
+		return &compiledPresenceRange{
+			required: required,
+			checker:  CompileChecker(checker, valueType),
+		}, true
+	}
+}
+
+type compiledPresenceRange struct {
+	required bool
+	deref    bool
+	checker  CompiledChecker
+}
+
+func (c *compiledPresenceRange) CheckValue(value reflect.Value, report *Report) bool {
+	valueType := value.Type()
+	for {
+		switch value.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			if value.IsNil() {
+				if c.required {
+					failAbsent(report, valueType)
+					return false
+				}
+				return true
+			}
+		}
+		if !c.deref || value.Kind() != reflect.Ptr {
+			return c.checker.CheckValue(value, report)
+		}
+		value = value.Elem()
+	}
+}
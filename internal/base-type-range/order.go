@@ -0,0 +1,213 @@
+package basetyperange
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+/*
+列表中的行要按顺序排好，如升级经验要严格递增、掉落权重的档位要从大到小排好
+
+ 1. asc         - 从小到大，可以相等
+ 2. strict_asc  - 严格递增，不能相等
+ 3. desc        - 从大到小，可以相等
+ 4. strict_desc - 严格递减，不能相等
+
+没有字段时按元素本身排，否则按行中的一个字段排，嵌套结构体的字段如 Tag.Sort
+每一个和前一行顺序不对的行都记一条，记在这一行的字段上，如：
+level.csv:4:B: levelCfg[2].Exp: order strict_asc broken, Exp=100 after Exp=120 at levelCfg[1].Exp (level.csv:3:B)
+
+元素不能重复用 unique，见 AddUnique
+.
+*/
+
+type Order int
+
+const (
+	OrderAsc        Order = iota // 从小到大，可以相等
+	OrderStrictAsc               // 严格递增
+	OrderDesc                    // 从大到小，可以相等
+	OrderStrictDesc              // 严格递减
+)
+
+var orderNames = []string{"asc", "strict_asc", "desc", "strict_desc"}
+
+func (o Order) String() string {
+	if o < 0 || int(o) >= len(orderNames) {
+		return fmt.Sprintf("Order(%d)", int(o))
+	}
+	return orderNames[o]
+}
+
+// 按名字找到顺序，如 strict_asc，规则文件中用
+func ParseOrder(name string) (Order, error) {
+	for i, orderName := range orderNames {
+		if orderName == name {
+			return Order(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown order %q, want one of: asc, strict_asc, desc, strict_desc", name)
+}
+
+// 后一行和前一行比较的结果是否符合顺序
+func (o Order) ok(c int) bool {
+	switch o {
+	case OrderAsc:
+		return c >= 0
+	case OrderStrictAsc:
+		return c > 0
+	case OrderDesc:
+		return c <= 0
+	default:
+		return c < 0
+	}
+}
+
+type orderRule struct {
+	order Order
+	field string // 为空时是元素本身
+}
+
+func (o orderRule) ToString() string {
+	if o.field == "" {
+		return "order(" + o.order.String() + ")"
+	}
+	return "order(" + o.field + " " + o.order.String() + ")"
+}
+
+func (o orderRule) fields() []string {
+	if o.field == "" {
+		return nil
+	}
+	return []string{o.field}
+}
+
+// 列表中的行要按 order 排好，field 为空时按元素本身，否则按行中的这个字段
+func (lr *ListRange) AddOrder(order Order, field string) *ListRange {
+	if order < OrderAsc || order > OrderStrictDesc {
+		panic(fmt.Sprintf("order illegal: %d", int(order)))
+	}
+	lr.orders = append(lr.orders, orderRule{order: order, field: field})
+	return lr
+}
+
+func checkOrders(orders []orderRule, rows []uniqueRow, report *Report) bool {
+	pass := true
+	for _, o := range orders {
+		if !o.check(rows, report) {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+	return pass
+}
+
+func (o orderRule) check(rows []uniqueRow, report *Report) bool {
+	pass := true
+	var prev any
+	var prevSeg PathSeg
+	hasPrev := false
+	for _, row := range rows {
+		value, err := o.rowValue(row.value)
+		var msg string
+		if err == nil && hasPrev {
+			var c int
+			c, err = compareOrderValue(value, prev)
+			if err == nil && !o.order.ok(c) {
+				msg = fmt.Sprintf("order %s broken, %s after %s", o.order.String(), o.valueString(value), o.valueString(prev))
+				if report != nil {
+					msg += " at " + rowWhere(report, prevSeg, o.fields())
+				}
+			}
+		}
+		if err != nil {
+			// 取不到值的行不参与比较，下一行和再前面的行比
+			msg = fmt.Sprintf("%s error: %v", o.ToString(), err)
+		} else {
+			prev, prevSeg, hasPrev = value, row.seg, true
+		}
+		if msg == "" {
+			continue
+		}
+		failAtRow(report, row.seg, o.fields(), msg)
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+func (o orderRule) rowValue(row reflect.Value) (any, error) {
+	if o.field == "" {
+		return elemValue(row)
+	}
+	return rowField(row, o.field)
+}
+
+func (o orderRule) valueString(value any) string {
+	if o.field == "" {
+		return fmt.Sprintf("value %v", value)
+	}
+	return fmt.Sprintf("%s=%v", o.field, value)
+}
+
+// 比较两个值，整数、浮点数、字符串，自定义的 type MyInt int 这些按底层类型
+// json.Number 按数字比较，不是按字符串
+func compareOrderValue(a, b any) (int, error) {
+	av, bv := reflect.ValueOf(orderNumber(a)), reflect.ValueOf(orderNumber(b))
+	switch {
+	case isIntKind(av.Kind()) && isIntKind(bv.Kind()):
+		return cmp.Compare(av.Int(), bv.Int()), nil
+	case isUintKind(av.Kind()) && isUintKind(bv.Kind()):
+		return cmp.Compare(av.Uint(), bv.Uint()), nil
+	case av.Kind() == reflect.String && bv.Kind() == reflect.String:
+		return cmp.Compare(av.String(), bv.String()), nil
+	}
+	af, aok := orderFloat(av)
+	bf, bok := orderFloat(bv)
+	if !aok || !bok {
+		return 0, fmt.Errorf("can not compare %T with %T", a, b)
+	}
+	return cmp.Compare(af, bf), nil
+}
+
+// 没有 sample 加载的 json 中的数字是 json.Number，Kind 是 string，先转为 int64 或 float64
+func orderNumber(value any) any {
+	n, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i64Value, err := n.Int64(); err == nil {
+		return i64Value
+	}
+	if f64Value, err := n.Float64(); err == nil {
+		return f64Value
+	}
+	return value
+}
+
+func orderFloat(value reflect.Value) (float64, bool) {
+	switch {
+	case isIntKind(value.Kind()):
+		return float64(value.Int()), true
+	case isUintKind(value.Kind()):
+		return float64(value.Uint()), true
+	case value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64:
+		return value.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
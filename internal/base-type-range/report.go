@@ -0,0 +1,190 @@
+package basetyperange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 值路径中的一段，字段名 / 数组下标 / map key 三选一
+type PathSeg struct {
+	Field string // 结构体字段名
+	Index int    // 数组下标
+	Key   any    // map 的 key
+	IsKey bool   // 是否是 map key
+}
+
+func (ps PathSeg) isIndex() bool {
+	return ps.Field == "" && !ps.IsKey
+}
+
+// 路径中这一段的字符串表示，如 [2]、[gem]、.Skins
+func (ps PathSeg) String() string {
+	switch {
+	case ps.IsKey:
+		return fmt.Sprintf("[%v]", ps.Key)
+	case ps.isIndex():
+		return fmt.Sprintf("[%d]", ps.Index)
+	default:
+		return "." + ps.Field
+	}
+}
+
+// 一条检测不通过的记录
+type Violation struct {
+	Path string // 出错的值所在的路径，如 heroCfg[2].Skins[0]
+	Pos  string // 数据来源中的位置，如 hero.csv:17:C，没有来源信息的时候为空
+	Msg  string // 不通过的原因
+}
+
+func (v Violation) String() string {
+	if v.Pos != "" {
+		return fmt.Sprintf("%s: %s: %s", v.Pos, v.Path, v.Msg)
+	}
+	return fmt.Sprintf("%s: %s", v.Path, v.Msg)
+}
+
+// 数据来源定位，由加载数据的一方提供，把值路径转为数据来源中的位置
+type Locator interface {
+	Locate(path []PathSeg) string
+}
+
+// 行的名字，如 Id=61403，没有名字的时候返回 false
+type RowName func(seg PathSeg) (string, bool)
+
+// 检测报告，容器类的 checker 在检测子元素的时候，会把路径一层层压进来
+// 所有方法对 nil 都是安全的，nil 表示不需要诊断信息，只要一个 bool 的结果就行
+type Report struct {
+	Root    string  // 路径的根，一般是配置表的 key
+	Locator Locator // 可选，数据来源定位
+	RowName RowName // 可选，第一层的行的名字，声明了主键的配置表路径中用 heroCfg[Id=61403] 代替 heroCfg[2]
+
+	path       []PathSeg
+	Violations []Violation
+	Warnings   []Violation // 不影响是否通过的提醒，如 strict 模式为 StrictWarn 时没有规则的字段
+}
+
+func NewReport(root string) *Report {
+	return &Report{Root: root}
+}
+
+func (r *Report) PushField(name string) {
+	if r == nil {
+		return
+	}
+	r.path = append(r.path, PathSeg{Field: name})
+}
+
+func (r *Report) PushIndex(index int) {
+	if r == nil {
+		return
+	}
+	r.path = append(r.path, PathSeg{Index: index})
+}
+
+func (r *Report) PushKey(key any) {
+	if r == nil {
+		return
+	}
+	r.path = append(r.path, PathSeg{Key: key, IsKey: true})
+}
+
+func (r *Report) Pop() {
+	if r == nil || len(r.path) == 0 {
+		return
+	}
+	r.path = r.path[:len(r.path)-1]
+}
+
+// 记录一条不通过的信息，路径取当前压入的路径
+func (r *Report) Failf(format string, args ...any) {
+	if r == nil {
+		return
+	}
+	r.Violations = append(r.Violations, r.newViolation(format, args...))
+}
+
+// 记录一条提醒，不影响是否通过
+func (r *Report) Warnf(format string, args ...any) {
+	if r == nil {
+		return
+	}
+	r.Warnings = append(r.Warnings, r.newViolation(format, args...))
+}
+
+func (r *Report) newViolation(format string, args ...any) Violation {
+	pos := ""
+	if r.Locator != nil {
+		pos = r.Locator.Locate(r.path)
+	}
+	return Violation{
+		Path: r.PathString(),
+		Pos:  pos,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (r *Report) Pass() bool {
+	return r == nil || len(r.Violations) == 0
+}
+
+// 当前路径的字符串表示
+func (r *Report) PathString() string {
+	if r == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(r.Root)
+	for i, seg := range r.path {
+		if i == 0 && r.RowName != nil && seg.Field == "" {
+			if name, ok := r.RowName(seg); ok {
+				fmt.Fprintf(&sb, "[%s]", name)
+				continue
+			}
+		}
+		if seg.Field != "" && sb.Len() == 0 { // 没有根的时候第一个字段前面不要点
+			sb.WriteString(seg.Field)
+			continue
+		}
+		sb.WriteString(seg.String())
+	}
+	return sb.String()
+}
+
+func (r *Report) String() string {
+	if r == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(r.Violations))
+	for _, v := range r.Violations {
+		lines = append(lines, v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 能输出诊断信息的 checker，容器类的 checker 都实现了这个接口
+type reportChecker interface {
+	CheckWithReport(value any, report *Report) bool
+}
+
+// 带诊断信息的检测
+// checker 没有实现 reportChecker 的话，不通过时用 checker 的描述记一条通用的信息
+func CheckWithReport(checker baseChecker, value any, report *Report) bool {
+	if rc, ok := checker.(reportChecker); ok {
+		return rc.CheckWithReport(value, report)
+	}
+	if checker.Check(value) {
+		return true
+	}
+	report.Failf("value %v (%T) not match %s", value, value, describe(checker))
+	return false
+}
+
+// checker 的描述，用于诊断信息
+func describe(checker any) string {
+	if s, ok := checker.(interface{ ToString() string }); ok {
+		if str := s.ToString(); str != "" {
+			return str
+		}
+	}
+	return fmt.Sprintf("%T", checker)
+}
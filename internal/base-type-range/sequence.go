@@ -0,0 +1,177 @@
+package basetyperange
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+/*
+列表、map 中的值要是连续的，如等级表要有 1..N 级不能断、章节 Id 要连续
+
+范围和 IntRange 的区间写法一样：
+ 1. [1,-]  - 从 1 开始连续，到最大的值为止
+ 2. [1,10] - 正好是 1..10，少了的记为 missing，范围外的记为 extra
+ 3. (0,-)  - 和 [1,-] 一样
+
+没有字段时列表是元素本身、map 是 key，否则是行中的字段；值只能是整数
+重复的值不算，不能重复用 unique
+不通过时记在容器本身上，如：
+levelCfg: sequence(Level) [1,10] not contiguous, missing: 4, 7-9, extra: 0
+.
+*/
+
+type sequenceRule struct {
+	field      string // 为空时列表是元素本身、map 是 key
+	valueRange *IntRange
+}
+
+func newSequenceRule(field, rangeStr string) sequenceRule {
+	if rangeStr == "" {
+		panic("sequence range is empty")
+	}
+	return sequenceRule{field: field, valueRange: IntValueRangerChecker(rangeStr)}
+}
+
+func (s sequenceRule) name() string {
+	if s.field == "" {
+		return "sequence"
+	}
+	return "sequence(" + s.field + ")"
+}
+
+// 列表中的值要在 rangeStr 中连续，field 为空时是元素本身，否则是行中的这个字段
+func (lr *ListRange) AddSequence(field, rangeStr string) *ListRange {
+	lr.sequences = append(lr.sequences, newSequenceRule(field, rangeStr))
+	return lr
+}
+
+// map 中的值要在 rangeStr 中连续，field 为空时是 key，否则是 value 中的这个字段
+func (mr *MapRange) AddSequence(field, rangeStr string) *MapRange {
+	mr.sequences = append(mr.sequences, newSequenceRule(field, rangeStr))
+	return mr
+}
+
+func checkSequences(sequences []sequenceRule, rows []uniqueRow, report *Report) bool {
+	pass := true
+	for _, s := range sequences {
+		if s.check(rows, report) {
+			continue
+		}
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+func (s sequenceRule) check(rows []uniqueRow, report *Report) bool {
+	values := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		value, err := s.rowValue(row)
+		if err != nil {
+			msg := fmt.Sprintf("%s error: at %s: %v", s.name(), row.seg.String(), err)
+			Debugf("%s\n", msg)
+			report.Failf("%s", msg)
+			return false
+		}
+		values = append(values, value)
+	}
+	slices.Sort(values)
+	values = slices.Compact(values)
+
+	first, last, bounded := s.bounds()
+	if !bounded && len(values) > 0 && values[len(values)-1] > last {
+		last = values[len(values)-1]
+	}
+	var missing, extra []intSpan
+	next := first
+	for _, value := range values {
+		if value < first || value > last {
+			extra = appendSpan(extra, value, value)
+			continue
+		}
+		if next < value {
+			missing = appendSpan(missing, next, value-1)
+		}
+		next = value + 1
+	}
+	if (len(values) > 0 || bounded) && next <= last {
+		missing = appendSpan(missing, next, last)
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true
+	}
+
+	msg := fmt.Sprintf("%s %s not contiguous", s.name(), s.valueRange.originalStr)
+	if len(missing) > 0 {
+		msg += ", missing: " + spansString(missing)
+	}
+	if len(extra) > 0 {
+		msg += ", extra: " + spansString(extra)
+	}
+	Debugf("%s\n", msg)
+	report.Failf("%s", msg)
+	return false
+}
+
+// 第一个和最后一个值，没有上限时 last 是 first - 1，bounded 为 false
+func (s sequenceRule) bounds() (first, last int64, bounded bool) {
+	first = s.valueRange.min
+	if !s.valueRange.inclusiveMin {
+		first++
+	}
+	if s.valueRange.noLimitMax {
+		return first, first - 1, false
+	}
+	last = s.valueRange.max
+	if !s.valueRange.inclusiveMax {
+		last--
+	}
+	return first, last, true
+}
+
+func (s sequenceRule) rowValue(row uniqueRow) (int64, error) {
+	var value any
+	var err error
+	switch {
+	case s.field != "":
+		value, err = rowField(row.value, s.field)
+	case row.seg.IsKey:
+		value = row.seg.Key
+	default:
+		value, err = elemValue(row.value)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return intValue(value)
+}
+
+// 一段连续的值
+type intSpan struct {
+	from, to int64
+}
+
+// 按从小到大的顺序加入，和前一段连上的合并起来
+func appendSpan(spans []intSpan, from, to int64) []intSpan {
+	if n := len(spans); n > 0 && spans[n-1].to+1 == from {
+		spans[n-1].to = to
+		return spans
+	}
+	return append(spans, intSpan{from: from, to: to})
+}
+
+// 如 4, 7-9
+func spansString(spans []intSpan) string {
+	parts := make([]string, 0, len(spans))
+	for _, span := range spans {
+		if span.from == span.to {
+			parts = append(parts, fmt.Sprint(span.from))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", span.from, span.to))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -17,5 +17,5 @@ func (sr *StringRange) Check(value any) bool {
 }
 
 func (sr *StringRange) ToString() string {
-	return ""
+	return "string"
 }
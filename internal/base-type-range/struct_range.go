@@ -1,8 +1,13 @@
 package basetyperange
 
 import (
-	"fmt"
+	"encoding/json"
+	"math"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 func StructValueRangerChecker(checker any) *StructRange {
@@ -10,25 +15,97 @@ func StructValueRangerChecker(checker any) *StructRange {
 	if checkerType.Kind() != reflect.Struct { // checker 必须是 struct 类型
 		panic("struct range checker must be struct type")
 	}
-	count := checkerType.NumField()
-	if count == 0 {
+
+	sr := EmptyStructValueRangerChecker()
+	sr.addCheckerFields(checkerType, reflect.ValueOf(checker))
+	if len(sr.fieldNames) == 0 {
 		panic("struct range checker has no field")
 	}
-	checkerValue := reflect.ValueOf(checker)
 
-	mapChecker := make(map[string]baseChecker)
-	for i := 0; i < count; i++ {
-		fieldName := checkerType.Field(i).Name
-		fieldCheckerValue := checkerValue.Field(i).Interface()
-		fieldChecker, ok := fieldCheckerValue.(baseChecker)
+	return sr
+}
+
+// checker 结构体中嵌入的结构体（不是 checker 的），它的字段和 Go 中一样提升上来，对应值中被提升的字段
+func (sr *StructRange) addCheckerFields(checkerType reflect.Type, checkerValue reflect.Value) {
+	for i := 0; i < checkerType.NumField(); i++ {
+		field := checkerType.Field(i)
+		fieldValue := checkerValue.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && !field.Type.Implements(baseCheckerType) {
+			sr.addCheckerFields(field.Type, fieldValue)
+			continue
+		}
+		if !field.IsExported() {
+			panic("struct range checker field: " + field.Name + " is not exported")
+		}
+		fieldChecker, ok := fieldValue.Interface().(baseChecker)
 		if !ok {
-			panic("struct range checker field: " + fieldName + " is not a baseChecker")
+			panic("struct range checker field: " + field.Name + " is not a baseChecker")
 		}
-		mapChecker[fieldName] = fieldChecker
+		sr.AddField(field.Name, fieldChecker)
 	}
+}
 
+var baseCheckerType = reflect.TypeOf((*baseChecker)(nil)).Elem()
+
+// 没有字段的 struct checker，再通过 AddField 一个个加字段
+// 给 tag、规则文件这些不是用 checker 结构体来描述规则的地方使用
+func EmptyStructValueRangerChecker() *StructRange {
 	return &StructRange{
-		mapChecker: mapChecker,
+		mapChecker: make(map[string]baseChecker),
+	}
+}
+
+func (sr *StructRange) AddField(fieldName string, fieldChecker baseChecker) *StructRange {
+	if fieldChecker == nil {
+		panic("struct range checker field: " + fieldName + " checker is nil")
+	}
+	if _, ok := sr.mapChecker[fieldName]; ok {
+		panic("struct range checker field duplicate: " + fieldName)
+	}
+	sr.mapChecker[fieldName] = fieldChecker
+	sr.fieldNames = append(sr.fieldNames, fieldName)
+	return sr
+}
+
+// 用 struct checker 检测 map[string]any 这样的值时，字段名就是 map 的 key
+// 如 json.Unmarshal 到 any 中的动态协议字段，少 key、多 key 的处理方式由这个选项决定
+type MapKeyOptions struct {
+	AllowMissing bool // 少了 checker 中的 key 也算通过，默认和 struct 少字段一样不通过
+	RejectExtra  bool // 有 checker 中没有的 key 时不通过，默认和 struct 多字段一样不管
+	// 值中 json 的数字（json.Number、float64）是整数时转为整数再检测，int、enum、ref 规则都能用；默认按原来的类型检测
+	// 只转换这个 struct checker 直接检测的 key 的值，值是 []any、map 时其中的元素不转换，
+	// 这些元素要用 Any 或者自定义的 checker 检测，IntRange、EnumRange、RefRange 本身不认 float64
+	JSONNumber bool
+}
+
+func (sr *StructRange) WithMapKeyOptions(opts MapKeyOptions) *StructRange {
+	sr.mapKeyOptions = opts
+	return sr
+}
+
+// 严格模式：值的类型中有没有规则的导出字段时怎么处理
+// 配置表加了新列，checker 却忘了加，新列就一直没有被检测，打开严格模式就能发现
+type StrictMode int
+
+const (
+	StrictOff  StrictMode = iota // 不管，默认
+	StrictWarn                   // 记一条提醒，检测还是通过
+	StrictFail                   // 检测不通过
+)
+
+func (sr *StructRange) WithStrict(mode StrictMode) *StructRange {
+	sr.strict = mode
+	return sr
+}
+
+// 规则都一样、没有严格模式的副本，uncheckedMap 不能复制，副本中重新缓存
+func (sr *StructRange) withoutStrict() *StructRange {
+	return &StructRange{
+		mapChecker:    sr.mapChecker,
+		fieldNames:    sr.fieldNames,
+		mapKeyOptions: sr.mapKeyOptions,
+		switches:      sr.switches,
+		constraints:   sr.constraints,
 	}
 }
 
@@ -39,60 +116,380 @@ type baseChecker interface {
 
 type StructRange struct {
 	mapChecker map[string]baseChecker
+	fieldNames []string // checker 中字段的声明顺序，按这个顺序去检测，诊断信息的顺序才是稳定的
+
+	mapKeyOptions MapKeyOptions
+	strict        StrictMode
+	switches      []*SwitchRange // 按分支字段的值选择的规则，字段都检测完之后再检测
+	constraints   []*Constraint  // 结构体级别的约束，分支也检测完之后再检测
+	uncheckedMap  sync.Map       // reflect.Type => []string，值的类型中没有规则的字段，按类型缓存
 }
 
-func (sr *StructRange) prt2OriThenCheck(value any) bool {
-	valueType := reflect.TypeOf(value)
-	if valueType.Kind() != reflect.Ptr { // 必须是指针类型
-		fmt.Printf("value no ptr, is: %s\n", valueType.Kind().String())
-		return false
-	}
+// 解开所有层的指针，获得其真正的 struct 或 map 再去检测
+// protobuf 生成的消息中到处是指针，**T 这样的也按 T 检测
+func (sr *StructRange) prt2OriThenCheck(value any, report *Report) bool {
 	valueValue := reflect.ValueOf(value)
-	oriValue := valueValue.Elem().Interface()
-
-	oriValueType := reflect.TypeOf(oriValue)
-	if oriValueType.Kind() != reflect.Struct { // 不要搞指针套指针，只能一层指针指向 struct
-		fmt.Printf("value no struct, is: %s\n", oriValueType.Kind().String())
+	if valueValue.Kind() != reflect.Ptr { // 必须是指针类型
+		Debugf("value no ptr, is: %s\n", valueValue.Kind().String())
+		report.Failf("value no ptr, is: %s", valueValue.Kind().String())
 		return false
 	}
+	for valueValue.Kind() == reflect.Ptr {
+		if valueValue.IsNil() { // 不能解引用，需要允许不填的话用 OptionalValueRangerChecker 包一层
+			Debugf("value is nil ptr, type: %s\n", reflect.TypeOf(value).String())
+			report.Failf("value is nil ptr, type: %s", reflect.TypeOf(value).String())
+			return false
+		}
+		valueValue = valueValue.Elem()
+	}
 
-	return sr.Check(oriValue)
+	return sr.CheckWithReport(valueValue.Interface(), report)
 }
 
 func (sr *StructRange) Check(value any) bool {
+	return sr.CheckWithReport(value, nil)
+}
+
+func (sr *StructRange) CheckWithReport(value any, report *Report) bool {
+	if value == nil {
+		Debugf("value is nil\n")
+		report.Failf("value is nil")
+		return false
+	}
 	valueType := reflect.TypeOf(value)
 
 	if valueType.Kind() == reflect.Ptr { // 如果是指针类型，获得其真正的 struct 再去检测
-		return sr.prt2OriThenCheck(value)
+		return sr.prt2OriThenCheck(value, report)
+	}
+
+	if isStringKeyMap(valueType) {
+		return sr.checkMap(value, report)
 	}
 
 	if valueType.Kind() != reflect.Struct { // 必须是结构体类型
-		fmt.Printf("value no struct, is: %s\n", valueType.Kind().String())
+		Debugf("value no struct, is: %s\n", valueType.Kind().String())
+		report.Failf("value no struct, is: %s", valueType.Kind().String())
 		return false
 	}
 
-	valueNumFieldsLen := valueType.NumField()
-	checkerNumFieldsLen := len(sr.mapChecker)
-	if checkerNumFieldsLen > valueNumFieldsLen { // cheker 字段更多，肯定少字段了，不用检测就知道不通过了
+	pass := true
+	valueValue := reflect.ValueOf(value)
+	for _, fieldName := range sr.fieldNames {
+		fieldChecker := sr.mapChecker[fieldName]
+		// 嵌入结构体中被提升的字段也能找到
+		field, ok := valueType.FieldByName(fieldName)
+		if !ok { // value 中没有对应的字段，检测不通过
+			report.PushField(fieldName)
+			report.Failf("value has no field: %s", fieldName)
+			report.Pop()
+			if report == nil {
+				return false
+			}
+			pass = false
+			continue
+		}
+		fieldValue, err := valueValue.FieldByIndexErr(field.Index)
+		if err != nil { // 字段在 nil 的嵌入结构体指针中
+			failNilEmbedded(report, fieldName, valueType)
+			if report == nil {
+				return false
+			}
+			pass = false
+			continue
+		}
+		fieldValue, ok = readableField(fieldValue)
+		if !ok { // 未导出的列表、map、结构体等拿不到值
+			failUnexported(report, fieldName, fieldValue.Type())
+			if report == nil {
+				return false
+			}
+			pass = false
+			continue
+		}
+		valueFieldValue := fieldValue.Interface()
+
+		report.PushField(fieldName)
+		ok = CheckWithReport(fieldChecker, valueFieldValue, report) // 去检测该字段的值是否符合范围
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+
+	if len(sr.switches) > 0 && !sr.checkSwitches(valueValue, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+
+	if len(sr.constraints) > 0 && !sr.checkConstraints(valueValue, report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+
+	if sr.strict != StrictOff && !sr.checkUnchecked(sr.uncheckedFields(valueType), report) {
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+
+	return pass
+}
+
+func failNilEmbedded(report *Report, fieldName string, valueType reflect.Type) {
+	Debugf("value field: %s is in nil embedded ptr, type: %s\n", fieldName, valueType.String())
+	report.PushField(fieldName)
+	report.Failf("value field: %s is in nil embedded ptr, type: %s", fieldName, valueType.String())
+	report.Pop()
+}
+
+// 值的类型中没有规则的导出字段
+// 嵌入的结构体看它提升上来的字段；字段所在的嵌入结构体整个有规则时，也算有规则
+func (sr *StructRange) uncheckedFields(valueType reflect.Type) []string {
+	if names, ok := sr.uncheckedMap.Load(valueType); ok {
+		return names.([]string)
+	}
+
+	fieldNames := sr.fieldNames
+	for _, sw := range sr.switches { // 分支中检测的字段也算有规则
+		fieldNames = append(slices.Clip(fieldNames), sw.caseFields()...)
+	}
+	var checked [][]int
+	for _, fieldName := range fieldNames {
+		if field, ok := valueType.FieldByName(fieldName); ok {
+			checked = append(checked, field.Index)
+		}
+	}
+	var names []string
+	for _, field := range reflect.VisibleFields(valueType) {
+		if !field.IsExported() || (field.Anonymous && derefType(field.Type).Kind() == reflect.Struct) {
+			continue
+		}
+		if indexCovered(field.Index, checked) {
+			continue
+		}
+		names = append(names, field.Name)
+	}
+	sr.uncheckedMap.Store(valueType, names)
+	return names
+}
+
+// 按严格模式处理没有规则的字段，StrictWarn 时只记提醒
+func (sr *StructRange) checkUnchecked(names []string, report *Report) bool {
+	pass := true
+	for _, name := range names {
+		report.PushField(name)
+		if sr.strict == StrictFail {
+			Debugf("value field: %s has no rule\n", name)
+			report.Failf("value field: %s has no rule (strict)", name)
+			pass = false
+		} else {
+			report.Warnf("value field: %s has no rule", name)
+		}
+		report.Pop()
+		if !pass && report == nil {
+			return false
+		}
+	}
+	return pass
+}
+
+// index 是否是某个 prefix 本身或在它里面
+func indexCovered(index []int, prefixes [][]int) bool {
+	for _, prefix := range prefixes {
+		if len(index) >= len(prefix) && slices.Equal(index[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(valueType reflect.Type) reflect.Type {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	return valueType
+}
+
+// 未导出的字段不能 Interface()，基础类型的值可以拷贝一份出来再检测，保持原来的类型
+// 列表、map、结构体、指针等拷贝不出来，返回原来的值和 false
+func readableField(fieldValue reflect.Value) (reflect.Value, bool) {
+	if fieldValue.CanInterface() {
+		return fieldValue, true
+	}
+	if !IsBasicKind(fieldValue.Kind()) {
+		return fieldValue, false
+	}
+	copied := reflect.New(fieldValue.Type()).Elem()
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		copied.SetInt(fieldValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		copied.SetUint(fieldValue.Uint())
+	case reflect.Float32, reflect.Float64:
+		copied.SetFloat(fieldValue.Float())
+	case reflect.String:
+		copied.SetString(fieldValue.String())
+	case reflect.Bool:
+		copied.SetBool(fieldValue.Bool())
+	}
+	return copied, true
+}
+
+// 未导出时也能读出来检测的类型
+func IsBasicKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	default:
 		return false
 	}
+}
 
+func failUnexported(report *Report, fieldName string, fieldType reflect.Type) {
+	Debugf("value field: %s is unexported, type %s can not be read\n", fieldName, fieldType.String())
+	report.PushField(fieldName)
+	report.Failf("value field: %s is unexported, type %s can not be read, only basic types can be checked", fieldName, fieldType.String())
+	report.Pop()
+}
+
+func isStringKeyMap(valueType reflect.Type) bool {
+	return valueType.Kind() == reflect.Map && valueType.Key().Kind() == reflect.String
+}
+
+// json 中是整数的数字，不是负数的转为 uint64，负数转为 int64，[]any、map[string]any 中的也一起转，其他的值原样返回
+// ref 区分有无符号，配置表的 Id 一般是无符号的，所以引用 int 这些有符号字段的 ref 对不上
+// 默认解析出来的是 float64，超过 2^53 的整数会丢精度，这种大数要用 Decoder.UseNumber 解析成 json.Number
+func jsonNumberValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if u64Value, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u64Value
+		}
+		if i64Value, err := v.Int64(); err == nil {
+			return i64Value
+		}
+	case float64:
+		if v == math.Trunc(v) && v >= 0 && v < math.MaxUint64 {
+			return uint64(v)
+		}
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < 0 {
+			return int64(v)
+		}
+	case []any:
+		converted := make([]any, len(v))
+		for i, elem := range v {
+			converted[i] = jsonNumberValue(elem)
+		}
+		return converted
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, elem := range v {
+			converted[key] = jsonNumberValue(elem)
+		}
+		return converted
+	}
+	return value
+}
+
+// 按字段名检测 map[string]any 这样的值
+func (sr *StructRange) checkMap(value any, report *Report) bool {
+	pass := true
 	valueValue := reflect.ValueOf(value)
-	for fieldName, fieldChecker := range sr.mapChecker {
-		_, ok := valueType.FieldByName(fieldName)
-		if !ok { // value 中没有对应的字段，检测不通过
+	keyType := valueValue.Type().Key()
+	for _, fieldName := range sr.fieldNames {
+		fieldValue := valueValue.MapIndex(reflect.ValueOf(fieldName).Convert(keyType))
+		if !fieldValue.IsValid() { // map 中没有这个 key
+			if sr.mapKeyOptions.AllowMissing {
+				continue
+			}
+			report.PushField(fieldName)
+			report.Failf("value has no key: %s", fieldName)
+			report.Pop()
+			if report == nil {
+				return false
+			}
+			pass = false
+			continue
+		}
+
+		fieldAny := fieldValue.Interface()
+		if sr.mapKeyOptions.JSONNumber {
+			fieldAny = jsonNumberValue(fieldAny)
+		}
+		report.PushField(fieldName)
+		ok := CheckWithReport(sr.mapChecker[fieldName], fieldAny, report)
+		report.Pop()
+		if !ok {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+
+	if len(sr.switches) > 0 && !sr.checkSwitches(valueValue, report) {
+		if report == nil {
 			return false
 		}
-		valueFieldValue := valueValue.FieldByName(fieldName).Interface()
+		pass = false
+	}
 
-		if !fieldChecker.Check(valueFieldValue) { // 去检测该字段的值是否符合范围
+	if len(sr.constraints) > 0 && !sr.checkConstraints(valueValue, report) {
+		if report == nil {
 			return false
 		}
+		pass = false
 	}
 
-	return true
+	if sr.mapKeyOptions.RejectExtra || sr.strict != StrictOff {
+		var extraKeys []string
+		for _, key := range mapKeys(valueValue, true) {
+			if _, ok := sr.mapChecker[key.String()]; !ok && !sr.switchChecks(key.String()) {
+				extraKeys = append(extraKeys, key.String())
+			}
+		}
+		if sr.mapKeyOptions.RejectExtra {
+			for _, keyStr := range extraKeys {
+				Debugf("value has unknown key: %s\n", keyStr)
+				report.PushField(keyStr)
+				report.Failf("value has unknown key: %s", keyStr)
+				report.Pop()
+				if report == nil {
+					return false
+				}
+				pass = false
+			}
+		} else if !sr.checkUnchecked(extraKeys, report) { // 严格模式下多的 key 就是没有规则的字段
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+
+	return pass
+}
+
+// 某个字段是否在分支中检测了
+func (sr *StructRange) switchChecks(fieldName string) bool {
+	for _, sw := range sr.switches {
+		if slices.Contains(sw.caseFields(), fieldName) {
+			return true
+		}
+	}
+	return false
 }
 
 func (sr *StructRange) ToString() string {
-	return ""
+	return "struct{" + strings.Join(sr.fieldNames, ",") + "}"
 }
@@ -0,0 +1,190 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+)
+
+/*
+按某个字段（分支字段）的值选择检测规则，如奖励表：
+
+	Type 是 item 时 Value 必须是 itemCfg.Id，是 currency 时 Value 是货币的枚举，是 exp 时 Value 是一个区间
+
+ 1. 分支字段的值按 fieldexpr.Normalize 转换后比较，整数、字符串、bool 都可以，自定义的 type RewardType int 也可以
+ 2. case 的 checker 拿到的是整行，一般是只有变化的那几个字段的 struct checker
+ 3. 没有匹配的 case 时用 default 的 checker，没有 default 时检测不通过
+
+可以直接当一个字段的 checker 用，也可以通过 StructRange.AddSwitch 加到检测整行的 struct checker 上
+.
+*/
+
+type SwitchRange struct {
+	field          string                 // 分支字段，嵌套结构体的字段如 Reward.Type
+	cases          map[string]baseChecker // 分支字段的值 => checker
+	caseKeys       []string               // 排好序的 case，诊断信息的顺序才是稳定的
+	defaultChecker baseChecker            // 没有匹配的 case 时用，可以是 nil
+}
+
+// 没有 case 的 switch checker，再通过 AddCase 一个个加 case
+func SwitchValueRangerChecker(field string) *SwitchRange {
+	if field == "" {
+		panic("switch range field is empty")
+	}
+	return &SwitchRange{
+		field: field,
+		cases: make(map[string]baseChecker),
+	}
+}
+
+// value 是分支字段的值，int 的 1 和 string 的 "1" 是同一个 case，规则文件中的 case 只能写成字符串
+func (sw *SwitchRange) AddCase(value any, checker baseChecker) *SwitchRange {
+	key, ok := switchKey(value)
+	if !ok {
+		panic(fmt.Sprintf("switch range field: %s case value: %v type %T illegal, want int, string, bool", sw.field, value, value))
+	}
+	if checker == nil {
+		panic(fmt.Sprintf("switch range field: %s case: %s checker is nil", sw.field, key))
+	}
+	if _, ok := sw.cases[key]; ok {
+		panic(fmt.Sprintf("switch range field: %s case duplicate: %s", sw.field, key))
+	}
+	sw.cases[key] = noStrict(checker)
+	sw.caseKeys = append(sw.caseKeys, key)
+	slices.Sort(sw.caseKeys)
+	return sw
+}
+
+func (sw *SwitchRange) WithDefault(checker baseChecker) *SwitchRange {
+	sw.defaultChecker = noStrict(checker)
+	return sw
+}
+
+// case 的 struct checker 拿到的是整行，整行中别的字段都是没有规则的，不能再按严格模式检测
+// 严格模式由 AddSwitch 加到的外面的 struct checker 统一处理
+// 不能直接改传进来的 checker，它可能还在别的地方按严格模式用，所以 case 中用一个关掉了严格模式的副本
+// 规则是共用的，case 的 checker 要在 AddCase 之前建好
+func noStrict(checker baseChecker) baseChecker {
+	if sr, ok := checker.(*StructRange); ok && sr.strict != StrictOff {
+		return sr.withoutStrict()
+	}
+	return checker
+}
+
+func switchKey(value any) (string, bool) {
+	normalized, ok := fieldexpr.Normalize(value)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(normalized), true
+}
+
+func (sw *SwitchRange) Check(value any) bool {
+	return sw.CheckWithReport(value, nil)
+}
+
+func (sw *SwitchRange) CheckWithReport(value any, report *Report) bool {
+	if value == nil {
+		Debugf("value is nil\n")
+		report.Failf("value is nil")
+		return false
+	}
+	return sw.checkRow(reflect.ValueOf(value), report)
+}
+
+// row 是结构体、结构体指针或 map[string]any 这样的值
+func (sw *SwitchRange) checkRow(row reflect.Value, report *Report) bool {
+	checker, ok := sw.pick(row, report)
+	if !ok {
+		return false
+	}
+	if !row.CanInterface() {
+		Debugf("switch %s row %s can not be read\n", sw.field, row.Type().String())
+		report.Failf("switch %s row %s can not be read", sw.field, row.Type().String())
+		return false
+	}
+	return CheckWithReport(checker, row.Interface(), report)
+}
+
+// 按分支字段的值选择 checker，分支字段读不出来、没有匹配的 case 时检测不通过，记在分支字段上
+func (sw *SwitchRange) pick(row reflect.Value, report *Report) (baseChecker, bool) {
+	var msg string
+	value, err := rowField(row, sw.field)
+	if err == nil {
+		key, ok := switchKey(value)
+		if !ok {
+			msg = fmt.Sprintf("switch %s value type %T illegal, want int, string, bool", sw.field, value)
+		} else if checker, ok := sw.cases[key]; ok {
+			return checker, true
+		} else if sw.defaultChecker != nil {
+			return sw.defaultChecker, true
+		} else {
+			msg = fmt.Sprintf("switch %s value: %s has no case, want one of: %s", sw.field, key, strings.Join(sw.caseKeys, ", "))
+		}
+	} else {
+		msg = fmt.Sprintf("switch %s error: %v", sw.field, err)
+	}
+
+	Debugf("%s\n", msg)
+	names := strings.Split(sw.field, ".")
+	for _, name := range names {
+		report.PushField(name)
+	}
+	report.Failf("%s", msg)
+	for range names {
+		report.Pop()
+	}
+	return nil, false
+}
+
+// 所有 case 中的 struct checker 检测了的字段，严格模式下这些字段不算没有规则
+func (sw *SwitchRange) caseFields() []string {
+	var names []string
+	checkers := make([]baseChecker, 0, len(sw.caseKeys)+1)
+	for _, key := range sw.caseKeys {
+		checkers = append(checkers, sw.cases[key])
+	}
+	checkers = append(checkers, sw.defaultChecker)
+	for _, checker := range checkers {
+		if sr, ok := checker.(*StructRange); ok {
+			names = append(names, sr.fieldNames...)
+		}
+	}
+	return names
+}
+
+func (sw *SwitchRange) ToString() string {
+	parts := make([]string, 0, len(sw.caseKeys)+1)
+	for _, key := range sw.caseKeys {
+		parts = append(parts, key+":"+describe(sw.cases[key]))
+	}
+	if sw.defaultChecker != nil {
+		parts = append(parts, "default:"+describe(sw.defaultChecker))
+	}
+	return "switch " + sw.field + "{" + strings.Join(parts, ",") + "}"
+}
+
+// 加到 struct checker 上，字段都检测完之后按分支字段的值再检测
+func (sr *StructRange) AddSwitch(sw *SwitchRange) *StructRange {
+	if sw == nil {
+		panic("struct range switch is nil")
+	}
+	sr.switches = append(sr.switches, sw)
+	return sr
+}
+
+func (sr *StructRange) checkSwitches(row reflect.Value, report *Report) bool {
+	pass := true
+	for _, sw := range sr.switches {
+		if !sw.checkRow(row, report) {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+	return pass
+}
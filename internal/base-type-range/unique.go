@@ -0,0 +1,215 @@
+package basetyperange
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+)
+
+/*
+列表、map 中的行不能重复，如 heroCfg 中两行的 Id 都是 61401
+
+ 1. 没有字段时元素本身不能重复，如 Skins 中不能有两个一样的皮肤
+ 2. 一个字段，如 Id
+ 3. 多个字段的组合，如 Type + Value，组合起来不能重复
+
+每一个重复的行都记一条，记在重复的行的第一个字段上，信息中带上第一次出现的行的位置，如：
+hero.csv:5:A: heroCfg[3].Id: duplicate Id=61401, same as heroCfg[0].Id (hero.csv:2:A)
+.
+*/
+
+type uniqueRule struct {
+	fields []string // 嵌套结构体的字段如 Tag.Free，为空时是元素本身
+}
+
+func newUniqueRule(fields []string) uniqueRule {
+	for _, field := range fields {
+		if field == "" {
+			panic("unique field is empty")
+		}
+	}
+	return uniqueRule{fields: fields}
+}
+
+func (u uniqueRule) ToString() string {
+	if len(u.fields) == 0 {
+		return "unique"
+	}
+	return "unique(" + strings.Join(u.fields, ",") + ")"
+}
+
+// 列表中的元素不能重复，fields 为空时是元素本身，否则是这些字段的组合
+func (lr *ListRange) AddUnique(fields ...string) *ListRange {
+	lr.uniques = append(lr.uniques, newUniqueRule(fields))
+	return lr
+}
+
+// map 中的 value 不能重复，fields 的用法同 ListRange.AddUnique
+func (mr *MapRange) AddUnique(fields ...string) *MapRange {
+	mr.uniques = append(mr.uniques, newUniqueRule(fields))
+	return mr
+}
+
+// 检测列表、map 中的行不能重复，不用先创建 checker，如加载配置表时检测主键
+func CheckUnique(value any, report *Report, fields ...string) bool {
+	valueValue := reflect.ValueOf(value)
+	switch valueValue.Kind() {
+	case reflect.Array, reflect.Slice:
+		return newUniqueRule(fields).check(listRows(valueValue), report)
+	case reflect.Map:
+		return newUniqueRule(fields).check(mapRows(valueValue, true), report)
+	default:
+		report.Failf("value no list or map, is: %s", valueValue.Kind().String())
+		return false
+	}
+}
+
+// 一行和它在容器中的位置
+type uniqueRow struct {
+	seg   PathSeg
+	value reflect.Value
+}
+
+func listRows(listValue reflect.Value) []uniqueRow {
+	rows := make([]uniqueRow, 0, listValue.Len())
+	for i := 0; i < listValue.Len(); i++ {
+		rows = append(rows, uniqueRow{seg: PathSeg{Index: i}, value: listValue.Index(i)})
+	}
+	return rows
+}
+
+func mapRows(mapValue reflect.Value, sorted bool) []uniqueRow {
+	entries := mapEntries(mapValue, sorted)
+	rows := make([]uniqueRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, uniqueRow{seg: PathSeg{Key: entry.key.Interface(), IsKey: true}, value: entry.value})
+	}
+	return rows
+}
+
+func checkUniques(uniques []uniqueRule, rows []uniqueRow, report *Report) bool {
+	pass := true
+	for _, u := range uniques {
+		if !u.check(rows, report) {
+			if report == nil {
+				return false
+			}
+			pass = false
+		}
+	}
+	return pass
+}
+
+func (u uniqueRule) check(rows []uniqueRow, report *Report) bool {
+	pass := true
+	seen := make(map[string]PathSeg, len(rows))
+	for _, row := range rows {
+		key, values, err := u.rowKey(row.value)
+		var msg string
+		if err != nil {
+			msg = fmt.Sprintf("%s error: %v", u.ToString(), err)
+		} else if first, ok := seen[key]; !ok {
+			seen[key] = row.seg
+			continue
+		} else {
+			msg = "duplicate " + values
+			if report != nil {
+				msg += ", same as " + rowWhere(report, first, u.fields)
+			}
+		}
+		failAtRow(report, row.seg, u.fields, msg)
+		if report == nil {
+			return false
+		}
+		pass = false
+	}
+	return pass
+}
+
+// 一行的 key 和用于诊断信息的值，如 Id=61401
+func (u uniqueRule) rowKey(row reflect.Value) (string, string, error) {
+	if len(u.fields) == 0 {
+		value, err := elemValue(row)
+		if err != nil {
+			return "", "", err
+		}
+		return uniqueKeyPart(value), fmt.Sprintf("value %v", value), nil
+	}
+	keys := make([]string, 0, len(u.fields))
+	values := make([]string, 0, len(u.fields))
+	for _, name := range u.fields {
+		value, err := rowField(row, name)
+		if err != nil {
+			return "", "", err
+		}
+		keys = append(keys, uniqueKeyPart(value))
+		values = append(values, fmt.Sprintf("%s=%v", name, value))
+	}
+	return strings.Join(keys, "\x00"), strings.Join(values, ", "), nil
+}
+
+// 元素本身，指针、interface 会解开
+func elemValue(value reflect.Value) (any, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, fmt.Errorf("value is nil")
+		}
+		value = value.Elem()
+	}
+	readable, ok := readableField(value)
+	if !ok {
+		return nil, fmt.Errorf("value type %s can not be read", value.Type().String())
+	}
+	return readable.Interface(), nil
+}
+
+// 整数、字符串、bool 按 fieldexpr.Normalize 转换，int 的 1 和 uint64 的 1 是重复的
+func uniqueKeyPart(value any) string {
+	if normalized, ok := fieldexpr.Normalize(value); ok {
+		value = normalized
+	}
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+// 在行的第一个字段上记一条，fields 为空时记在行上
+func failAtRow(report *Report, seg PathSeg, fields []string, msg string) {
+	Debugf("%s\n", msg)
+	if report == nil {
+		return
+	}
+	depth := report.pushRowField(seg, fields)
+	report.Failf("%s", msg)
+	for ; depth > 0; depth-- {
+		report.Pop()
+	}
+}
+
+// 另一行的路径，如第一次出现的行，有数据来源时带上位置
+func rowWhere(report *Report, seg PathSeg, fields []string) string {
+	depth := report.pushRowField(seg, fields)
+	where := report.PathString()
+	if report.Locator != nil {
+		if pos := report.Locator.Locate(report.path); pos != "" {
+			where += " (" + pos + ")"
+		}
+	}
+	for ; depth > 0; depth-- {
+		report.Pop()
+	}
+	return where
+}
+
+// 压入行的位置和第一个字段的路径，返回压入了几层
+func (r *Report) pushRowField(seg PathSeg, fields []string) int {
+	r.path = append(r.path, seg)
+	depth := 1
+	if len(fields) > 0 {
+		for _, name := range strings.Split(fields[0], ".") {
+			r.PushField(name)
+			depth++
+		}
+	}
+	return depth
+}
@@ -0,0 +1,275 @@
+package csvtable
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+)
+
+/*
+csv 配置表，解析为行结构体的 slice
+
+ 1. 第一行是表头，每一列对应行结构体的一个字段，嵌套结构体的字段用 Tag.Free 这样的列名
+ 2. 单元格中：
+    * int 系列、string、bool 直接解析，空单元格是零值
+    * 列表用 ListSep 分隔，如 6140101;6140102
+    * map 的每一项用 ListSep 分隔，每一项中用 KVSep 分隔 key 和 value，如 1:100;2:200
+
+解析的时候记下每一行在文件中的行号、每一列的列名，检测不通过的时候可以指出 hero.csv:17:C
+.
+*/
+
+type Options struct {
+	Comma   rune   // 列分隔符，默认 ,
+	ListSep string // 列表元素分隔符，默认 ;
+	KVSep   string // map 的 key 和 value 的分隔符，默认 :
+}
+
+func (o Options) withDefault() Options {
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	if o.ListSep == "" {
+		o.ListSep = ";"
+	}
+	if o.KVSep == "" {
+		o.KVSep = ":"
+	}
+	return o
+}
+
+// 解析好的表
+type Table struct {
+	File string
+	Rows any // 行结构体的 slice
+
+	columns  []column
+	rowLines []int // 每一行数据在文件中的行号
+}
+
+type column struct {
+	name  string // 表头中的列名，如 Tag.Free
+	index []int  // 对应的字段下标
+}
+
+// 解析 csv，rowsType 是行结构体的 slice 类型
+func Decode(file string, r io.Reader, rowsType reflect.Type, opts Options) (*Table, error) {
+	if rowsType.Kind() != reflect.Slice || rowsType.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: csv table must be decoded into a slice of struct, is: %s", file, rowsType.String())
+	}
+	opts = opts.withDefault()
+	rowType := rowsType.Elem()
+
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Comma
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: read header failed: %v", file, err)
+	}
+
+	table := &Table{File: file}
+	seen := make(map[string]int) // 字段下标 => 列号，Id 和 Base.Id 这样不同写法的同一个字段也算重复
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		index, err := fieldIndexByPath(rowType, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s:1:%s: %v", file, ColumnName(i), err)
+		}
+		key := fmt.Sprint(index)
+		if j, ok := seen[key]; ok {
+			return nil, fmt.Errorf("%s:1:%s: column %q duplicate, same field as column %s", file, ColumnName(i), name, ColumnName(j))
+		}
+		seen[key] = i
+		table.columns = append(table.columns, column{name: name, index: index})
+	}
+
+	rows := reflect.MakeSlice(rowsType, 0, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		line, _ := reader.FieldPos(0)
+		row := reflect.New(rowType).Elem()
+		for i, cell := range record {
+			if err := setCell(fieldByIndexAlloc(row, table.columns[i].index), cell, opts); err != nil {
+				cellLine, _ := reader.FieldPos(i)
+				return nil, fmt.Errorf("%s:%d:%s: %s: %v", file, cellLine, ColumnName(i), table.columns[i].name, err)
+			}
+		}
+		rows = reflect.Append(rows, row)
+		table.rowLines = append(table.rowLines, line)
+	}
+	table.Rows = rows.Interface()
+	return table, nil
+}
+
+// 把值路径转为 csv 中的位置，如 [16].Skins[1] => hero.csv:17:F
+func (t *Table) Locate(path []basetyperange.PathSeg) string {
+	if len(path) == 0 || path[0].Field != "" || path[0].IsKey {
+		return t.File
+	}
+	row := path[0].Index
+	if row < 0 || row >= len(t.rowLines) {
+		return t.File
+	}
+
+	// 连续的字段名拼成列名，取能对上的最长的那一列
+	var names []string
+	for _, seg := range path[1:] {
+		if seg.Field == "" {
+			break
+		}
+		names = append(names, seg.Field)
+	}
+	for n := len(names); n > 0; n-- {
+		name := strings.Join(names[:n], ".")
+		for i, col := range t.columns {
+			if col.name == name {
+				return fmt.Sprintf("%s:%d:%s", t.File, t.rowLines[row], ColumnName(i))
+			}
+		}
+	}
+	return fmt.Sprintf("%s:%d", t.File, t.rowLines[row])
+}
+
+// Tag.Free => 字段下标
+// 路径上的结构体指针在解析时分配，但不导出的嵌入指针（如 *base）没法分配，直接报错
+func fieldIndexByPath(rowType reflect.Type, path string) ([]int, error) {
+	var index []int
+	t := rowType
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("column %q: %s is not a struct", path, t.String())
+		}
+		field, ok := t.FieldByName(name)
+		if !ok || !field.IsExported() {
+			return nil, fmt.Errorf("column %q: no exported field %q in %s", path, name, t.String())
+		}
+		// 提升上来的字段，检查经过的嵌入字段
+		embedded := t
+		for _, i := range field.Index[:len(field.Index)-1] {
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			f := embedded.Field(i)
+			if f.Type.Kind() == reflect.Pointer && !f.IsExported() {
+				return nil, fmt.Errorf("column %q: field %q is in unexported embedded pointer %s, can not be set", path, name, f.Type.String())
+			}
+			embedded = f.Type
+		}
+		index = append(index, field.Index...)
+		t = field.Type
+	}
+	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return nil, fmt.Errorf("column %q is a struct, use columns like %s.Field", path, path)
+	}
+	return index, nil
+}
+
+// 和 FieldByIndex 一样，但路径上为 nil 的结构体指针会先分配
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+func setCell(v reflect.Value, cell string, opts Options) error {
+	cell = strings.TrimSpace(cell)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if cell == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse %s %q failed", v.Type().String(), cell)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if cell == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parse %s %q failed", v.Type().String(), cell)
+		}
+		v.SetUint(n)
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		if cell == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("parse bool %q failed", cell)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		if cell != "" {
+			for _, item := range strings.Split(cell, opts.ListSep) {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := setCell(elem, item, opts); err != nil {
+					return err
+				}
+				list = reflect.Append(list, elem)
+			}
+		}
+		v.Set(list)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		if cell != "" {
+			for _, item := range strings.Split(cell, opts.ListSep) {
+				keyStr, valueStr, ok := strings.Cut(item, opts.KVSep)
+				if !ok {
+					return fmt.Errorf("map item %q must be key%svalue", item, opts.KVSep)
+				}
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := setCell(key, keyStr, opts); err != nil {
+					return err
+				}
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := setCell(value, valueStr, opts); err != nil {
+					return err
+				}
+				m.SetMapIndex(key, value)
+			}
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("type %s can not be used in csv cell", v.Type().String())
+	}
+	return nil
+}
+
+// 列号（从 0 开始）转为 A、B ... Z、AA 这样的列名，和表格软件中看到的一样
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package expandtyperange
+
+import (
+	"reflect"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+)
+
+// 拓展类型的编译，检测的时候直接从 reflect.Value 中取 int64/uint64/string，不再做 type switch
+
+func (er *EnumRange) Compile(valueType reflect.Type) (basetyperange.CompiledChecker, bool) {
+	if !basetyperange.IsPredeclared(valueType) {
+		return nil, false
+	}
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &compiledEnumRange{er: er, signed: true}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &compiledEnumRange{er: er, signed: false}, true
+	default:
+		return nil, false
+	}
+}
+
+type compiledEnumRange struct {
+	er     *EnumRange
+	signed bool
+}
+
+func (c *compiledEnumRange) CheckValue(value reflect.Value, report *basetyperange.Report) bool {
+	var ok bool
+	if c.signed {
+		v := value.Int()
+		ok = v >= 0 && c.er.enumStore.CheckEnumValue(c.er.enumKey, uint64(v))
+	} else {
+		ok = c.er.enumStore.CheckEnumValue(c.er.enumKey, value.Uint())
+	}
+	if !ok {
+		basetyperange.FailValue(report, value, c.er)
+	}
+	return ok
+}
+
+func (rf *RefRange) Compile(valueType reflect.Type) (basetyperange.CompiledChecker, bool) {
+	if !basetyperange.IsPredeclared(valueType) {
+		return nil, false
+	}
+	switch valueType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.String:
+		return &compiledRefRange{rf: rf, kind: valueType.Kind()}, true
+	default:
+		return nil, false
+	}
+}
+
+type compiledRefRange struct {
+	rf   *RefRange
+	kind reflect.Kind
+}
+
+func (c *compiledRefRange) CheckValue(value reflect.Value, report *basetyperange.Report) bool {
+	var ok bool
+	switch c.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ok = c.rf.refStore.CheckIntValue(c.rf.originalStr, value.Int())
+	case reflect.String:
+		ok = c.rf.refStore.CheckStrValue(c.rf.originalStr, value.String())
+	default:
+		ok = c.rf.refStore.CheckUintValue(c.rf.originalStr, value.Uint())
+	}
+	if !ok {
+		basetyperange.FailValue(report, value, c.rf)
+	}
+	return ok
+}
@@ -1,7 +1,10 @@
 package expandtyperange
 
 import (
-	"fmt"
+	"maps"
+	"slices"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
 )
 
 func EnumValueStore() *EnumStore {
@@ -15,9 +18,16 @@ type EnumStore struct {
 	oriEnumData map[string]map[uint64]struct{}
 }
 
+// 复制一份，枚举的数据是共用的，之后再加载的枚举互不影响
+func (es *EnumStore) Clone() *EnumStore {
+	clone := EnumValueStore()
+	maps.Copy(clone.oriEnumData, es.oriEnumData)
+	return clone
+}
+
 func (es *EnumStore) LoadOneEnum(enumKey string, enumData map[uint64]struct{}) bool {
 	if _, ok := es.oriEnumData[enumKey]; ok {
-		fmt.Printf("enum key duplicate load: %s\n", enumKey)
+		basetyperange.Debugf("enum key duplicate load: %s\n", enumKey)
 		return false
 	}
 
@@ -28,13 +38,13 @@ func (es *EnumStore) LoadOneEnum(enumKey string, enumData map[uint64]struct{}) b
 func (es *EnumStore) CheckEnumValue(enumKey string, value uint64) bool {
 	enumData, ok := es.oriEnumData[enumKey]
 	if !ok {
-		fmt.Printf("enum key not exit: %s\n", enumKey)
+		basetyperange.Debugf("enum key not exit: %s\n", enumKey)
 		return false
 	}
 
 	_, ok = enumData[value]
 	if !ok {
-		fmt.Printf("enum key: %s value: %d not exit\n", enumKey, value)
+		basetyperange.Debugf("enum key: %s value: %d not exit\n", enumKey, value)
 		return false
 	}
 
@@ -46,6 +56,20 @@ func (es *EnumStore) EnumRuleExit(enumKey string) bool {
 	return ok
 }
 
+// 枚举中所有的值，从小到大排好序
+func (es *EnumStore) EnumValues(enumKey string) ([]uint64, bool) {
+	enumData, ok := es.oriEnumData[enumKey]
+	if !ok {
+		return nil, false
+	}
+	values := make([]uint64, 0, len(enumData))
+	for value := range enumData {
+		values = append(values, value)
+	}
+	slices.Sort(values)
+	return values, true
+}
+
 func EnumValueRangerChecker(enumStore *EnumStore, enumKey string) *EnumRange {
 	if enumStore == nil {
 		panic("EnumValueRangerChecker enumStore is nil")
@@ -79,40 +103,45 @@ func (er *EnumRange) Check(value any) bool {
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	case int64:
 		if v < 0 {
-			fmt.Printf("EnumRange check value negative int64: %d\n", v)
+			basetyperange.Debugf("EnumRange check value negative int64: %d\n", v)
 			return false
 		}
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	case int32:
 		if v < 0 {
-			fmt.Printf("EnumRange check value negative int32: %d\n", v)
+			basetyperange.Debugf("EnumRange check value negative int32: %d\n", v)
 			return false
 		}
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	case int16:
 		if v < 0 {
-			fmt.Printf("EnumRange check value negative int16: %d\n", v)
+			basetyperange.Debugf("EnumRange check value negative int16: %d\n", v)
 			return false
 		}
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	case int8:
 		if v < 0 {
-			fmt.Printf("EnumRange check value negative int8: %d\n", v)
+			basetyperange.Debugf("EnumRange check value negative int8: %d\n", v)
 			return false
 		}
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	case int:
 		if v < 0 {
-			fmt.Printf("EnumRange check value negative int: %d\n", v)
+			basetyperange.Debugf("EnumRange check value negative int: %d\n", v)
 			return false
 		}
 		return er.enumStore.CheckEnumValue(er.enumKey, uint64(v))
 	default:
-		fmt.Printf("EnumRange check value type no support, type: %T\n", v)
+		basetyperange.Debugf("EnumRange check value type no support, type: %T\n", v)
 		return false
 	}
 }
 
+// 已经是 uint64 的值，直接检测是否是枚举值
+func (er *EnumRange) CheckUint64(value uint64) bool {
+	return er.enumStore.CheckEnumValue(er.enumKey, value)
+}
+
 func (er *EnumRange) ToString() string {
-	return ""
+	return "enum(" + er.enumKey + ")"
 }
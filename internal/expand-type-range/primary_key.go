@@ -0,0 +1,139 @@
+package expandtyperange
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+)
+
+// 主键有重复的值
+var ErrPrimaryKeyDuplicate = errors.New("primary key duplicate")
+
+// 一张配置表按主键建的索引
+// 配置表是列表或 map，行是结构体或结构体指针，主键是行中的一个 int、uint、string 字段
+type tableIndex struct {
+	field string
+	data  reflect.Value
+
+	rows  map[string]basetyperange.PathSeg // 主键的值 => 行的位置
+	names map[string]string                // 行的位置 => 行的名字，如 Id=61403
+	keys  []any                            // 所有主键的值，uint64、int64、string，ref 主键的时候直接用
+}
+
+// 给加载过的配置表声明主键，检测主键是唯一的并建立索引
+// 主键有重复时返回 ErrPrimaryKeyDuplicate，不建立索引
+func (rs *RefStore) SetPrimaryKey(key, field string) error {
+	index, err := rs.newTableIndex(key, field)
+	if err != nil {
+		return err
+	}
+	rs.indexes[key] = index
+	return nil
+}
+
+// 同 SetPrimaryKey，只检测能不能声明，不建立索引
+func (rs *RefStore) CheckPrimaryKey(key, field string) error {
+	_, err := rs.newTableIndex(key, field)
+	return err
+}
+
+func (rs *RefStore) newTableIndex(key, field string) (*tableIndex, error) {
+	oriData, ok := rs.oriData[key]
+	if !ok {
+		return nil, fmt.Errorf("primary key no load ori data key: %s", key)
+	}
+	if _, ok := rs.indexes[key]; ok {
+		return nil, fmt.Errorf("primary key dup set, key: %s", key)
+	}
+
+	index := &tableIndex{
+		field: field,
+		data:  reflect.ValueOf(oriData),
+		rows:  make(map[string]basetyperange.PathSeg),
+		names: make(map[string]string),
+	}
+	var segs []basetyperange.PathSeg
+	switch index.data.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < index.data.Len(); i++ {
+			segs = append(segs, basetyperange.PathSeg{Index: i})
+		}
+	case reflect.Map:
+		mapKeys := index.data.MapKeys()
+		sort.Slice(mapKeys, func(i, j int) bool {
+			return fmt.Sprint(mapKeys[i].Interface()) < fmt.Sprint(mapKeys[j].Interface())
+		})
+		for _, mapKey := range mapKeys {
+			segs = append(segs, basetyperange.PathSeg{Key: mapKey.Interface(), IsKey: true})
+		}
+	default:
+		return nil, fmt.Errorf("primary key ori data type no list or map, key: %s, type: %s", key, index.data.Kind().String())
+	}
+
+	for _, seg := range segs {
+		value, err := index.keyValue(index.row(seg))
+		if err != nil {
+			return nil, fmt.Errorf("primary key %s.%s at %s: %v", key, field, seg.String(), err)
+		}
+		rowKey := fmt.Sprint(value)
+		if _, ok := index.rows[rowKey]; ok {
+			return nil, fmt.Errorf("%w: %s.%s=%s", ErrPrimaryKeyDuplicate, key, field, rowKey)
+		}
+		index.rows[rowKey] = seg
+		index.names[seg.String()] = fmt.Sprintf("%s=%s", field, rowKey)
+		index.keys = append(index.keys, value)
+	}
+	return index, nil
+}
+
+func (ti *tableIndex) row(seg basetyperange.PathSeg) reflect.Value {
+	if seg.IsKey {
+		return ti.data.MapIndex(reflect.ValueOf(seg.Key))
+	}
+	return ti.data.Index(seg.Index)
+}
+
+// 行中主键的值，统一转为 uint64、int64、string，和 ref 这个字段时取值的方式一样
+func (ti *tableIndex) keyValue(row reflect.Value) (any, error) {
+	return refFieldValue(row, ti.field)
+}
+
+// 配置表的主键字段
+func (rs *RefStore) PrimaryKey(key string) (string, bool) {
+	index, ok := rs.indexes[key]
+	if !ok {
+		return "", false
+	}
+	return index.field, true
+}
+
+// 按主键找到一行，主键的值按 fieldexpr.Normalize 转换，int 的 1 和 uint64 的 1 是同一个
+func (rs *RefStore) LookupRow(key string, primaryKey any) (any, bool) {
+	index, ok := rs.indexes[key]
+	if !ok {
+		return nil, false
+	}
+	normalized, ok := fieldexpr.Normalize(primaryKey)
+	if !ok {
+		return nil, false
+	}
+	seg, ok := index.rows[fmt.Sprint(normalized)]
+	if !ok {
+		return nil, false
+	}
+	return index.row(seg).Interface(), true
+}
+
+// 行的名字，如 Id=61403，用在诊断信息的路径中
+func (rs *RefStore) RowName(key string, seg basetyperange.PathSeg) (string, bool) {
+	index, ok := rs.indexes[key]
+	if !ok {
+		return "", false
+	}
+	name, ok := index.names[seg.String()]
+	return name, ok
+}
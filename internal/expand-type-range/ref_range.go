@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
 )
 
 /*
@@ -13,7 +16,7 @@ import (
  3. \.                    		- 点号分隔符
  4. ([a-zA-Z_][a-zA-Z0-9_]*) 	- 第二个字段名
  5. $                     		- 字符串结束
-匹配： 严格匹配两级，格式为 xxx.xxx ，如果配置表是 struct 的，第二级直接是 struct 的字段名，如果是 map 或是 list 的话，要求其存放的数据的是 struct，然后，第二级直接是 struct 的字段名
+匹配： 严格匹配两级，格式为 xxx.xxx ，如果配置表是 struct 的，第二级直接是 struct 的字段名，如果是 map 或是 list 的话，要求其存放的数据的是 struct，然后，第二级直接是 struct 的字段名（struct 指针也可以）
  1. hero.id
  2. _hero._id
 .
@@ -44,6 +47,7 @@ var allowTypeSet = map[reflect.Kind]struct{}{
 func RefValueStore() *RefStore {
 	return &RefStore{
 		oriData: make(map[string]any),
+		indexes: make(map[string]*tableIndex),
 
 		mapStrRefCheckRule:  make(map[string]map[string]struct{}),
 		mapUintRefCheckRule: make(map[string]map[uint64]struct{}),
@@ -52,7 +56,8 @@ func RefValueStore() *RefStore {
 }
 
 type RefStore struct {
-	oriData map[string]any // 引入的原始数据
+	oriData map[string]any         // 引入的原始数据
+	indexes map[string]*tableIndex // 声明了主键的配置表的索引
 
 	// 缓存的 ref 的 id 规则，这样子就是加载的时候慢点，但是 check 的时候就快多了
 	// key: originalStr
@@ -100,7 +105,7 @@ func (rs *RefStore) CheckStrValue(originalStr string, value string) bool {
 // 这边可以把全部客户端配置都 load 进来
 func (rs *RefStore) LoadOneOriData(key string, data any) bool {
 	if _, ok := rs.oriData[key]; ok {
-		fmt.Printf("refrange dup load ori data, key: %s", key)
+		basetyperange.Debugf("refrange dup load ori data, key: %s", key)
 		return false
 	}
 
@@ -114,7 +119,7 @@ func (rs *RefStore) LoadOneOriData(key string, data any) bool {
 	// case reflect.Map:
 	// case reflect.Array, reflect.Slice:
 	// default:
-	// 	fmt.Printf("refrange load ori data type no support, key: %s, type: %s\n", key, tData.Kind().String())
+	// 	basetyperange.Debugf("refrange load ori data type no support, key: %s, type: %s\n", key, tData.Kind().String())
 	// 	return false
 	// }
 
@@ -122,6 +127,22 @@ func (rs *RefStore) LoadOneOriData(key string, data any) bool {
 	return true
 }
 
+// 获得加载过的原始数据
+func (rs *RefStore) OriData(key string) (any, bool) {
+	data, ok := rs.oriData[key]
+	return data, ok
+}
+
+// 所有加载过的原始数据的 key，按字典序排好
+func (rs *RefStore) OriDataKeys() []string {
+	keys := make([]string, 0, len(rs.oriData))
+	for key := range rs.oriData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (rs *RefStore) AddRefCheckRule(rangeStr string) string {
 	matches := refRangePattern.FindStringSubmatch(rangeStr)
 	if matches == nil {
@@ -144,6 +165,14 @@ func (rs *RefStore) AddRefCheckRule(rangeStr string) string {
 		panic(fmt.Sprintf("RefRange no load ori data key: %s", oriDataKey)) // 值范围描述字符串不合法
 	}
 
+	// ref 的是主键，索引中已经有所有的值了，不用再遍历一遍
+	if index, ok := rs.indexes[oriDataKey]; ok && index.field == fieldKey {
+		for _, value := range index.keys {
+			rs.addRefValue(originalStr, fieldKey, value)
+		}
+		return originalStr
+	}
+
 	// 解析 oriData，找到 fieldKey 这个值，获得值的类型
 	oriDataType := reflect.TypeOf(oriData)
 	switch oriDataType.Kind() {
@@ -160,26 +189,70 @@ func (rs *RefStore) AddRefCheckRule(rangeStr string) string {
 	return originalStr
 }
 
-// 配置表是个 struct 的
+// 配置表是个 struct 的，整张表就是一行
 // 检测 struct 类型的 fieldKey 字段类型是否符合 allowTypeSet 的要求
 // 并且转化为对应转化类型，加到缓存中
 func (rs *RefStore) checkStructFeildType(oriData any, originalStr, fieldKey string) {
-	oriDataType := reflect.TypeOf(oriData)
-	if oriDataType.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("RefRange ori data type no struct, type: %s", oriDataType.Kind().String()))
+	rs.addRowRefValue(reflect.ValueOf(oriData), originalStr, fieldKey, "")
+}
+
+// 一行中 fieldKey 字段的值加到缓存中，拿不到值时 panic，at 是行的位置，用于诊断信息
+func (rs *RefStore) addRowRefValue(row reflect.Value, originalStr, fieldKey, at string) {
+	value, err := refFieldValue(row, fieldKey)
+	if err != nil {
+		panic(fmt.Sprintf("RefRange ori data %s%s: %v", originalStr, at, err))
+	}
+	rs.addRefValue(originalStr, fieldKey, value)
+}
+
+// 行中 fieldKey 字段的值，统一转为 uint64、int64、string
+// 行可以是结构体、结构体指针，字段的类型可以是 type HeroId uint32 这样自定义的
+// 建主键索引的时候也是用它取值，ref 主键和 ref 其他字段时能用的表是一样的
+func refFieldValue(row reflect.Value, fieldKey string) (any, error) {
+	for row.Kind() == reflect.Ptr || row.Kind() == reflect.Interface {
+		if row.IsNil() {
+			return nil, fmt.Errorf("row is nil")
+		}
+		row = row.Elem()
+	}
+	if row.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("row type no struct, type: %s", row.Type().String())
 	}
-	oriDataFieldType, ok := oriDataType.FieldByName(fieldKey)
+	field, ok := row.Type().FieldByName(fieldKey)
 	if !ok {
-		panic(fmt.Sprintf("RefRange ori data no field key: %s", fieldKey))
+		return nil, fmt.Errorf("row no field: %s", fieldKey)
 	}
-	if _, ok := allowTypeSet[oriDataFieldType.Type.Kind()]; !ok {
-		panic(fmt.Sprintf("RefRange ori data field type no support, key: %s, type: %s", fieldKey, oriDataFieldType.Type.Kind().String()))
+	if !field.IsExported() { // 拿不到值，注册的时候就拒绝
+		return nil, fmt.Errorf("field key: %s is unexported", fieldKey)
 	}
+	fieldValue, err := row.FieldByIndexErr(field.Index)
+	if err != nil {
+		return nil, fmt.Errorf("field: %s is in nil embedded ptr", fieldKey)
+	}
+	if _, ok := allowTypeSet[fieldValue.Kind()]; !ok {
+		return nil, fmt.Errorf("field: %s type no support, type: %s", fieldKey, fieldValue.Type().String())
+	}
+	switch fieldValue.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fieldValue.Uint(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fieldValue.Int(), nil
+	default:
+		return fieldValue.String(), nil
+	}
+}
 
-	oriDataValue := reflect.ValueOf(oriData)
-	oriDataFeileValue := oriDataValue.FieldByName(fieldKey).Interface() // 获取字段的值，在 type 中获得过了，肯定会存在的
+// 列表、map 的元素要是结构体、结构体指针，没有数据的时候也能在注册的时候发现
+func checkRowType(rowType reflect.Type) bool {
+	for rowType.Kind() == reflect.Ptr {
+		rowType = rowType.Elem()
+	}
+	return rowType.Kind() == reflect.Struct || rowType.Kind() == reflect.Interface
+}
 
-	switch v := oriDataFeileValue.(type) {
+// 把 ref 的一个值加到缓存中
+func (rs *RefStore) addRefValue(originalStr, fieldKey string, value any) {
+	switch v := value.(type) {
 	case uint64:
 		if _, ok := rs.mapUintRefCheckRule[originalStr]; !ok {
 			rs.mapUintRefCheckRule[originalStr] = make(map[uint64]struct{})
@@ -238,6 +311,7 @@ func (rs *RefStore) checkStructFeildType(oriData any, originalStr, fieldKey stri
 	default:
 		panic(fmt.Sprintf("RefRange ori data field value type no support, key: %s, type: %T", fieldKey, v))
 	}
+
 }
 
 // 配置表是个 map 的
@@ -246,16 +320,14 @@ func (rs *RefStore) checkMapFeildType(oriData any, originalStr, fieldKey string)
 	if oriDataType.Kind() != reflect.Map {
 		panic(fmt.Sprintf("RefRange ori data type no map, type: %s", oriDataType.Kind().String()))
 	}
-	oriDataValueType := oriDataType.Elem() // 检测这个 map 的 value 类型是否是 struct
-	if oriDataValueType.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("RefRange ori data map elem value type no struct, type: %s", oriDataValueType.Kind().String()))
+	if !checkRowType(oriDataType.Elem()) { // 检测这个 map 的 value 类型是否是 struct
+		panic(fmt.Sprintf("RefRange ori data map elem value type no struct, type: %s", oriDataType.Elem().String()))
 	}
 
-	// 遍历这个 map，把每一个成员去调用 checkStructFeildType 函数
-	oriDataValue := reflect.ValueOf(oriData)
-	for _, key := range oriDataValue.MapKeys() {
-		mapValue := oriDataValue.MapIndex(key).Interface()
-		rs.checkStructFeildType(mapValue, originalStr, fieldKey)
+	// 遍历这个 map，把每一个成员的值加到缓存中
+	iter := reflect.ValueOf(oriData).MapRange()
+	for iter.Next() {
+		rs.addRowRefValue(iter.Value(), originalStr, fieldKey, fmt.Sprintf("[%v]", iter.Key().Interface()))
 	}
 }
 
@@ -265,16 +337,14 @@ func (rs *RefStore) checkListFeildType(oriData any, originalStr, fieldKey string
 	if oriDataType.Kind() != reflect.Array && oriDataType.Kind() != reflect.Slice {
 		panic(fmt.Sprintf("RefRange ori data type no list, type: %s", oriDataType.Kind().String()))
 	}
-	oriDataValueType := oriDataType.Elem() // 检测这个 list 的元素类型是否是 struct
-	if oriDataValueType.Kind() != reflect.Struct {
-		panic(fmt.Sprintf("RefRange ori data list elem value type no struct, type: %s", oriDataValueType.Kind().String()))
+	if !checkRowType(oriDataType.Elem()) { // 检测这个 list 的元素类型是否是 struct
+		panic(fmt.Sprintf("RefRange ori data list elem value type no struct, type: %s", oriDataType.Elem().String()))
 	}
 
-	// 遍历这个 list，把每一个成员去调用 checkStructFeildType 函数
+	// 遍历这个 list，把每一个成员的值加到缓存中
 	oriDataValue := reflect.ValueOf(oriData)
 	for i := 0; i < oriDataValue.Len(); i++ {
-		listValue := oriDataValue.Index(i).Interface()
-		rs.checkStructFeildType(listValue, originalStr, fieldKey)
+		rs.addRowRefValue(oriDataValue.Index(i), originalStr, fieldKey, fmt.Sprintf("[%d]", i))
 	}
 }
 
@@ -320,11 +390,24 @@ func (rf *RefRange) Check(value any) bool {
 	case string:
 		return rf.refStore.CheckStrValue(rf.originalStr, v)
 	default:
-		fmt.Printf("RefRange check value type no support, type: %T\n", v)
+		basetyperange.Debugf("RefRange check value type no support, type: %T\n", v)
 		return false
 	}
 }
 
+// 已经是 int64/uint64/string 的值，直接检测是否被引用的数据中存在
+func (rf *RefRange) CheckInt64(value int64) bool {
+	return rf.refStore.CheckIntValue(rf.originalStr, value)
+}
+
+func (rf *RefRange) CheckUint64(value uint64) bool {
+	return rf.refStore.CheckUintValue(rf.originalStr, value)
+}
+
+func (rf *RefRange) CheckString(value string) bool {
+	return rf.refStore.CheckStrValue(rf.originalStr, value)
+}
+
 func (rf *RefRange) ToString() string {
-	return ""
+	return "ref(" + rf.originalStr + ")"
 }
@@ -0,0 +1,563 @@
+package fieldexpr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+/*
+结构体字段之间的约束表达式，给 struct checker 的跨字段检测用
+
+	MinLevel <= MaxLevel
+	StartTime < EndTime
+	if Open then Quality >= 3
+	Type == "item" || Count > 0
+	Tag.Free && !Open
+
+ 1. 操作数：字段名（嵌套结构体的字段用 . 连接，如 Tag.Free）、整数、"字符串"、true、false
+ 2. 比较：== != < <= > >=，整数和整数比较、字符串和字符串比较，bool 只能 == !=
+ 3. 逻辑：! && ||，if A then B（A 不成立时整个表达式成立）
+ 4. 优先级从低到高：if-then、||、&&、!、比较，可以用括号改变
+
+字段的值由调用方提供，整数统一转为 int64 比较，和 IntRange 一样接受 json 解析出来的 json.Number、是整数的 float64
+.
+*/
+
+// 解析好的表达式
+type Expr struct {
+	src    string
+	root   node
+	fields []string // 用到的字段，按第一次出现的顺序
+}
+
+// 解析表达式，语法错误时返回的错误中带有列号（从 1 开始，按字节算）
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("col %d: unexpected %q", tok.col, tok.text)
+	}
+
+	e := &Expr{src: src, root: root}
+	seen := make(map[string]struct{})
+	walkFields(root, func(name string) {
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			e.fields = append(e.fields, name)
+		}
+	})
+	return e, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// 用到的字段，按第一次出现的顺序
+func (e *Expr) Fields() []string {
+	return e.fields
+}
+
+// 求值，get 根据字段名获得字段的值
+// 表达式的结果不是 bool、字段的类型不能比较等情况返回错误
+func (e *Expr) Eval(get func(name string) (any, error)) (bool, error) {
+	value, err := e.root.eval(get)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expr result is %s, not bool", typeName(value))
+	}
+	return b, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+/// 词法
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokInt
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // tokString 时是去掉引号、转义之后的内容
+	col  int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		col := i + 1
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], col: col})
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1]) && !lastIsOperand(tokens)):
+			start := i
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokInt, text: src[start:i], col: col})
+		case c == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("col %d: unterminated string", col)
+			}
+			str, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("col %d: invalid string %s", col, src[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokString, text: str, col: col})
+			i = end + 1
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("col %d: unexpected character %q", col, c)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, col: col})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of expr", col: len(src) + 1}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// 前一个 token 是操作数时，- 是减号而不是负数，不过表达式里没有减法，这样报错的位置更准
+func lastIsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind != tokOp || last.text == ")"
+}
+
+////////////////////////////////////////////////////////////////////////////////
+/// 语法
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && tok.text == word
+}
+
+// expr := 'if' or 'then' expr | or
+func (p *parser) parseExpr() (node, error) {
+	if !p.isKeyword("if") {
+		return p.parseOr()
+	}
+	p.next()
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword("then") {
+		tok := p.peek()
+		return nil, fmt.Errorf("col %d: want then, got %q", tok.col, tok.text)
+	}
+	p.next()
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &implyNode{cond: cond, then: then}, nil
+}
+
+// or := and ('||' and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+// and := unary ('&&' unary)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary := '!' unary | cmp
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+// cmp := primary (op primary)?
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: tok.text, col: tok.col, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+// primary := '(' expr ')' | ident | int | string | true | false
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("col %d: int %s out of range", tok.col, tok.text)
+		}
+		return &literalNode{value: n}, nil
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "if", "then":
+			return nil, fmt.Errorf("col %d: unexpected %q", tok.col, tok.text)
+		}
+		if strings.HasSuffix(tok.text, ".") || strings.Contains(tok.text, "..") {
+			return nil, fmt.Errorf("col %d: invalid field name %q", tok.col, tok.text)
+		}
+		return &fieldNode{name: tok.text}, nil
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				next := p.peek()
+				return nil, fmt.Errorf("col %d: want ), got %q", next.col, next.text)
+			}
+			p.next()
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("col %d: unexpected %q", tok.col, tok.text)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+/// 求值
+
+type node interface {
+	eval(get func(name string) (any, error)) (any, error)
+}
+
+func walkFields(n node, fn func(name string)) {
+	switch n := n.(type) {
+	case *fieldNode:
+		fn(n.name)
+	case *notNode:
+		walkFields(n.operand, fn)
+	case *logicNode:
+		walkFields(n.left, fn)
+		walkFields(n.right, fn)
+	case *compareNode:
+		walkFields(n.left, fn)
+		walkFields(n.right, fn)
+	case *implyNode:
+		walkFields(n.cond, fn)
+		walkFields(n.then, fn)
+	}
+}
+
+type literalNode struct {
+	value any // int64、string、bool
+}
+
+func (n *literalNode) eval(get func(name string) (any, error)) (any, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	name string
+}
+
+func (n *fieldNode) eval(get func(name string) (any, error)) (any, error) {
+	value, err := get(n.name)
+	if err != nil {
+		return nil, err
+	}
+	normalized, ok := Normalize(value)
+	if !ok {
+		return nil, fmt.Errorf("field %s type %s can not be used in expr", n.name, typeName(value))
+	}
+	return normalized, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(get func(name string) (any, error)) (any, error) {
+	b, err := evalBool(n.operand, get, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+type logicNode struct {
+	op          string // && ||
+	left, right node
+}
+
+func (n *logicNode) eval(get func(name string) (any, error)) (any, error) {
+	left, err := evalBool(n.left, get, n.op)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&" && !left) || (n.op == "||" && left) { // 短路，右边可能是在左边成立时才有意义的字段
+		return left, nil
+	}
+	return evalBool(n.right, get, n.op)
+}
+
+type implyNode struct {
+	cond, then node
+}
+
+func (n *implyNode) eval(get func(name string) (any, error)) (any, error) {
+	cond, err := evalBool(n.cond, get, "if")
+	if err != nil {
+		return nil, err
+	}
+	if !cond {
+		return true, nil
+	}
+	return evalBool(n.then, get, "then")
+}
+
+type compareNode struct {
+	op          string
+	col         int
+	left, right node
+}
+
+func (n *compareNode) eval(get func(name string) (any, error)) (any, error) {
+	left, err := n.left.eval(get)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(get)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case int64:
+		r, ok := right.(int64)
+		if !ok {
+			return nil, n.mismatch(left, right)
+		}
+		cmp = compareOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, n.mismatch(left, right)
+		}
+		cmp = compareOrdered(l, r)
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			return nil, n.mismatch(left, right)
+		}
+		switch n.op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		default:
+			return nil, fmt.Errorf("col %d: bool can not use %s", n.col, n.op)
+		}
+	default:
+		return nil, n.mismatch(left, right)
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default: // >=
+		return cmp >= 0, nil
+	}
+}
+
+func (n *compareNode) mismatch(left, right any) error {
+	return fmt.Errorf("col %d: can not compare %s %s %s", n.col, typeName(left), n.op, typeName(right))
+}
+
+func compareOrdered[T int64 | string](l, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func evalBool(n node, get func(name string) (any, error), op string) (bool, error) {
+	value, err := n.eval(get)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s need bool, got %s", op, typeName(value))
+	}
+	return b, nil
+}
+
+// 字段的值转为 int64、string、bool，自定义的 type MyInt int 这些也按底层类型，表达式之外按值分支的地方也用它
+func Normalize(value any) (any, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		i64Value, err := v.Int64()
+		return i64Value, err == nil
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return nil, false
+		}
+		return int64(v), true
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, false
+		}
+		return int64(rv.Uint()), true
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Bool:
+		return rv.Bool(), true
+	default:
+		return nil, false
+	}
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case int64:
+		return "int"
+	case nil:
+		return "nil"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
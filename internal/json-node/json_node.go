@@ -0,0 +1,272 @@
+package jsonnode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// 带位置信息的 json 解析
+// encoding/json 解析出来的值是没有位置的，规则文件、数据文件报错的时候，需要指出是在文件的哪一行哪一列
+
+// 在源文件中的位置
+type Pos struct {
+	File string
+	Line int // 从 1 开始
+	Col  int // 从 1 开始，按字节算
+}
+
+func (p Pos) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Col)
+}
+
+// 带位置的错误
+type PosError struct {
+	Pos Pos
+	Msg string
+}
+
+func (e *PosError) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+func Errorf(pos Pos, format string, args ...any) error {
+	return &PosError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Array
+	Object
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Null:
+		return "null"
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Object:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// json 中的一个值
+type Node struct {
+	Kind Kind
+	Pos  Pos
+
+	Bool   bool
+	Num    json.Number
+	Str    string
+	Elems  []*Node // Array 的元素
+	Fields []Field // Object 的成员，保持文件中的顺序
+}
+
+// Object 的一个成员
+type Field struct {
+	Key    string
+	KeyPos Pos
+	Value  *Node
+}
+
+// 按 key 获得 Object 的成员，没有的时候返回 nil
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != Object {
+		return nil
+	}
+	for i := range n.Fields {
+		if n.Fields[i].Key == key {
+			return n.Fields[i].Value
+		}
+	}
+	return nil
+}
+
+// 转为 encoding/json 解析出来的那种值，数字是 json.Number
+func (n *Node) Interface() any {
+	switch n.Kind {
+	case Bool:
+		return n.Bool
+	case Number:
+		return n.Num
+	case String:
+		return n.Str
+	case Array:
+		list := make([]any, 0, len(n.Elems))
+		for _, elem := range n.Elems {
+			list = append(list, elem.Interface())
+		}
+		return list
+	case Object:
+		obj := make(map[string]any, len(n.Fields))
+		for _, field := range n.Fields {
+			obj[field.Key] = field.Value.Interface()
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+// 解析 json，file 只用在位置信息中
+func Parse(file string, data []byte) (*Node, error) {
+	p := &parser{
+		file: file,
+		data: data,
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+	}
+	p.dec.UseNumber()
+	for i, c := range data {
+		if c == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+
+	node, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return nil, Errorf(p.pos(p.nextTokenOffset()), "unexpected data after top-level value")
+	}
+	return node, nil
+}
+
+// 偏移转为位置，给 encoding/json 的错误（只有 Offset）用
+func OffsetPos(file string, data []byte, offset int) Pos {
+	p := &parser{file: file, data: data}
+	for i, c := range data {
+		if c == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+	return p.pos(offset)
+}
+
+type parser struct {
+	file       string
+	data       []byte
+	dec        *json.Decoder
+	lineStarts []int // 第 2 行开始，每一行的起始偏移
+}
+
+// 偏移转为行列
+func (p *parser) pos(offset int) Pos {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset })
+	lineStart := 0
+	if line > 0 {
+		lineStart = p.lineStarts[line-1]
+	}
+	return Pos{File: p.file, Line: line + 1, Col: offset - lineStart + 1}
+}
+
+// Decoder 的 InputOffset 是上一个 token 的结尾，跳过空白和分隔符，才是下一个 token 的开始
+func (p *parser) nextTokenOffset() int {
+	offset := int(p.dec.InputOffset())
+	for offset < len(p.data) {
+		switch p.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func (p *parser) token() (json.Token, Pos, error) {
+	pos := p.pos(p.nextTokenOffset())
+	tok, err := p.dec.Token()
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, pos, Errorf(p.pos(int(syntaxErr.Offset)), "%s", syntaxErr.Error())
+		}
+		if err == io.EOF {
+			return nil, pos, Errorf(pos, "unexpected end of json")
+		}
+		return nil, pos, Errorf(pos, "%s", err.Error())
+	}
+	return tok, pos, nil
+}
+
+func (p *parser) parseValue() (*Node, error) {
+	tok, pos, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseFrom(tok, pos)
+}
+
+func (p *parser) parseFrom(tok json.Token, pos Pos) (*Node, error) {
+	switch v := tok.(type) {
+	case nil:
+		return &Node{Kind: Null, Pos: pos}, nil
+	case bool:
+		return &Node{Kind: Bool, Pos: pos, Bool: v}, nil
+	case json.Number:
+		return &Node{Kind: Number, Pos: pos, Num: v}, nil
+	case string:
+		return &Node{Kind: String, Pos: pos, Str: v}, nil
+	case json.Delim:
+		switch v {
+		case '[':
+			node := &Node{Kind: Array, Pos: pos}
+			for p.dec.More() {
+				elem, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				node.Elems = append(node.Elems, elem)
+			}
+			if _, _, err := p.token(); err != nil { // ]
+				return nil, err
+			}
+			return node, nil
+		case '{':
+			node := &Node{Kind: Object, Pos: pos}
+			keys := make(map[string]struct{})
+			for p.dec.More() {
+				keyTok, keyPos, err := p.token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string) // Decoder 保证 object 的 key 是 string
+				if _, ok := keys[key]; ok {
+					return nil, Errorf(keyPos, "duplicate key %q", key)
+				}
+				keys[key] = struct{}{}
+				value, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				node.Fields = append(node.Fields, Field{Key: key, KeyPos: keyPos, Value: value})
+			}
+			if _, _, err := p.token(); err != nil { // }
+				return nil, err
+			}
+			return node, nil
+		}
+	}
+	return nil, Errorf(pos, "unexpected token %v", tok)
+}
@@ -0,0 +1,626 @@
+package ruleschema
+
+import (
+	"slices"
+	"strings"
+
+	fieldexpr "github.com/chenjinjie/value-range/internal/field-expr"
+	jsonnode "github.com/chenjinjie/value-range/internal/json-node"
+)
+
+/*
+规则文件，策划不会改 Go 代码，但是可以改这个文件，格式是 json：
+
+	{
+		"enums": {
+			"heroCfgQuality": [1, 2, 3, 4, 5]
+		},
+		"tables": {
+			"heroCfg": {"list": {"struct": {
+				"Id":      "int",
+				"Quality": "enum=heroCfgQuality",
+				"Skins":   {"list": "ref=heroSkinCfg.Id"},
+				"Attrs":   {"map": {"key": "enum=heroCfgAttr", "value": "int=(0,-)"}},
+				"Tag":     {"struct": {"Free": "bool"}}
+			}}}
+		}
+	}
+
+ 1. enums  - 可选，枚举 key => 枚举值列表，等同于 LoadOneEnumCfg
+ 2. tables - 配置表 key => 这张表的规则，等同于 RegChecker
+
+规则：
+ 1. 字符串是基础类型的规则，和 vr tag 的写法一样：int、int=[0,10]、string、bool、bool=true、enum=<枚举key>、ref=<表.字段>
+ 2. {"list": <规则>}                              - 列表，规则是每个元素的规则
+ 3. {"map": {"key": <规则>, "value": <规则>}}     - map
+ 4. {"struct": {"<字段名>": <规则>, ...}}         - 结构体，字段名就是 Go 结构体中的字段名
+
+结构体还可以加上跨字段的约束，表达式的语法见 internal/field-expr：
+
+	{"struct": {"MinLevel": "int", "MaxLevel": "int"}, "checks": ["MinLevel <= MaxLevel"]}
+
+表的最外层可以写 "key" 声明主键，主键必须唯一，之后路径中用主键表示行，如 heroCfg[Id=61403].Skins：
+
+	"heroCfg": {"key": "Id", "list": {"struct": {"Id": "int"}}}
+
+列表、map 的长度用 "len" 限制，写法和 int 的区间一样，如正好 3 个、最多 10 个：
+
+	{"list": "ref=itemCfg.Id", "len": "[3,3]"}
+	{"map": {"key": "enum=heroCfgAttr", "value": "int"}, "len": "[0,10]"}
+
+列表、map 中的行不能重复，true 时是元素本身，数组时每一项是一条规则，字段或字段的组合：
+
+	{"list": {"struct": {"Id": "int", "Type": "string", "Value": "int"}}, "unique": ["Id", ["Type", "Value"]]}
+	{"list": "ref=heroSkinCfg.Id", "unique": true}
+
+列表中的行要按顺序排好，asc、strict_asc、desc、strict_desc，字符串时按元素本身，对象时按这些字段：
+
+	{"list": "int", "order": "strict_asc"}
+	{"list": {"struct": {"Level": "int", "Exp": "int"}}, "order": {"Level": "strict_asc", "Exp": "strict_asc"}}
+
+列表、map 中所有元素汇总起来的值要在区间内，sum、min、max、count，字符串时是元素本身、所有的行，
+对象时 sum、min、max 是字段 => 区间，count 是条件表达式 => 区间：
+
+	{"list": "int", "sum": "[0,100]"}
+	{"list": {"struct": {"ItemId": "ref=itemCfg.Id", "Weight": "int"}}, "sum": {"Weight": "[10000,10000]"}, "count": {"Weight == 0": "[0,0]"}}
+
+map 中要有的 key 用 "required_keys"，别的 key 不管；"enum_keys" 时 key 要正好是枚举中的所有值，不能少也不能多：
+
+	{"map": {"key": "string", "value": "int"}, "required_keys": ["hp", "mp"]}
+	{"map": {"key": "enum=heroCfgAttr", "value": "int"}, "enum_keys": "heroCfgAttr"}
+
+列表、map 中的值要连续，写法同 order，字符串时 list 是元素本身、map 是 key，报出缺了的值和区间外的值：
+
+	"levelCfg":   {"list": {"struct": {"Level": "int"}}, "sequence": {"Level": "[1,-]"}}
+	"chapterCfg": {"map": {"key": "int", "value": {"struct": {"Id": "int"}}}, "sequence": "[1,20]"}
+
+按某个字段的值选择规则，case 的规则检测整行，一般是 struct，没有 default 时值不在 case 中就不通过：
+
+	{"struct": {"Id": "int", "Type": "string"}, "switch": {"field": "Type", "cases": {
+		"item":     {"struct": {"Value": "ref=itemCfg.Id"}},
+		"currency": {"struct": {"Value": "enum=currency"}}
+	}, "default": {"struct": {"Value": "int=[1,-]"}}}}
+
+.
+*/
+
+// 一条规则
+type Rule struct {
+	Kind string // int、string、bool、enum、ref、list、map、struct
+	Arg  string // 基础类型规则的参数，如 int 的区间、enum 的 key
+
+	Elem            *Rule       // list 的元素、map 的 value
+	Key             *Rule       // map 的 key
+	Fields          []FieldRule // struct 的字段，保持文件中的顺序
+	Checks          []string    // struct 的约束表达式
+	Switch          *SwitchRule // struct 的分支
+	Unique          [][]string  // list、map 中不能重复的字段组合，空的组合是元素本身
+	Len             string      // list、map 的长度区间，和 int 的区间一样
+	LenPos          jsonnode.Pos
+	Orders          []OrderRule     // list 的顺序
+	Aggregates      []AggregateRule // list、map 汇总的值
+	Sequences       []SequenceRule  // list、map 中要连续的值
+	RequiredKeys    []string        // map 中要有的 key
+	RequiredKeysPos jsonnode.Pos
+	EnumKeys        string // map 的 key 要正好是这个枚举中的所有值
+	EnumKeysPos     jsonnode.Pos
+
+	Pos jsonnode.Pos
+}
+
+type FieldRule struct {
+	Name string
+	Rule *Rule
+}
+
+type OrderRule struct {
+	Field string // 为空时是元素本身
+	Order string // asc、strict_asc、desc、strict_desc
+	Pos   jsonnode.Pos
+}
+
+type AggregateRule struct {
+	Kind  string // sum、min、max、count
+	Arg   string // sum、min、max 的字段，count 的条件表达式，为空时是元素本身、所有的行
+	Range string // 和 int 的区间一样
+	Pos   jsonnode.Pos
+}
+
+type SequenceRule struct {
+	Field string // 为空时 list 是元素本身、map 是 key
+	Range string // 和 int 的区间一样
+	Pos   jsonnode.Pos
+}
+
+type SwitchRule struct {
+	Field   string
+	Cases   []SwitchCase // 保持文件中的顺序
+	Default *Rule
+	Pos     jsonnode.Pos
+}
+
+type SwitchCase struct {
+	Value string
+	Rule  *Rule
+	Pos   jsonnode.Pos
+}
+
+type Enum struct {
+	Key    string
+	Values []uint64
+	Pos    jsonnode.Pos
+}
+
+type Table struct {
+	Key  string
+	Rule *Rule
+	Pos  jsonnode.Pos
+
+	PrimaryKey    string // 可选，表的主键字段
+	PrimaryKeyPos jsonnode.Pos
+}
+
+type Schema struct {
+	Enums  []Enum
+	Tables []Table
+}
+
+func IsLeafKind(kind string) bool {
+	switch kind {
+	case "int", "string", "bool", "enum", "ref":
+		return true
+	default:
+		return false
+	}
+}
+
+// 解析规则文件，file 只用在错误信息中
+func Parse(file string, data []byte) (*Schema, error) {
+	root, err := jsonnode.Parse(file, data)
+	if err != nil {
+		return nil, err
+	}
+	if root.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(root.Pos, "rule file must be an object, is: %s", root.Kind)
+	}
+
+	schema := &Schema{}
+	for _, field := range root.Fields {
+		switch field.Key {
+		case "enums":
+			if schema.Enums, err = parseEnums(field.Value); err != nil {
+				return nil, err
+			}
+		case "tables":
+			if schema.Tables, err = parseTables(field.Value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, jsonnode.Errorf(field.KeyPos, "unknown key %q, want: enums, tables", field.Key)
+		}
+	}
+	return schema, nil
+}
+
+func parseEnums(node *jsonnode.Node) ([]Enum, error) {
+	if node.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(node.Pos, "enums must be an object, is: %s", node.Kind)
+	}
+	enums := make([]Enum, 0, len(node.Fields))
+	for _, field := range node.Fields {
+		if field.Value.Kind != jsonnode.Array {
+			return nil, jsonnode.Errorf(field.Value.Pos, "enum %q must be an array, is: %s", field.Key, field.Value.Kind)
+		}
+		enum := Enum{Key: field.Key, Pos: field.KeyPos}
+		for _, elem := range field.Value.Elems {
+			value, err := ParseUint(elem)
+			if err != nil {
+				return nil, err
+			}
+			enum.Values = append(enum.Values, value)
+		}
+		enums = append(enums, enum)
+	}
+	return enums, nil
+}
+
+func ParseUint(node *jsonnode.Node) (uint64, error) {
+	if node.Kind != jsonnode.Number {
+		return 0, jsonnode.Errorf(node.Pos, "want a non-negative integer, is: %s", node.Kind)
+	}
+	var value uint64
+	for _, c := range node.Num.String() {
+		if c < '0' || c > '9' {
+			return 0, jsonnode.Errorf(node.Pos, "want a non-negative integer, is: %s", node.Num)
+		}
+		next := value*10 + uint64(c-'0')
+		if next/10 != value {
+			return 0, jsonnode.Errorf(node.Pos, "integer overflow: %s", node.Num)
+		}
+		value = next
+	}
+	return value, nil
+}
+
+func parseTables(node *jsonnode.Node) ([]Table, error) {
+	if node.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(node.Pos, "tables must be an object, is: %s", node.Kind)
+	}
+	tables := make([]Table, 0, len(node.Fields))
+	for _, field := range node.Fields {
+		table := Table{Key: field.Key, Pos: field.KeyPos}
+		ruleNode := field.Value
+		if ruleNode.Kind == jsonnode.Object { // 表的主键只能写在最外层，拿出来之后剩下的才是规则
+			stripped := *ruleNode
+			stripped.Fields = nil
+			for _, f := range ruleNode.Fields {
+				if f.Key != "key" {
+					stripped.Fields = append(stripped.Fields, f)
+					continue
+				}
+				if f.Value.Kind != jsonnode.String || f.Value.Str == "" {
+					return nil, jsonnode.Errorf(f.Value.Pos, "table key must be a field name")
+				}
+				table.PrimaryKey, table.PrimaryKeyPos = f.Value.Str, f.Value.Pos
+			}
+			ruleNode = &stripped
+		}
+		rule, err := ParseRule(ruleNode)
+		if err != nil {
+			return nil, err
+		}
+		if table.PrimaryKey != "" && rule.Kind != "list" && rule.Kind != "map" {
+			return nil, jsonnode.Errorf(table.PrimaryKeyPos, "table key can only be used with list, map, is: %s", rule.Kind)
+		}
+		table.Rule = rule
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func ParseRule(node *jsonnode.Node) (*Rule, error) {
+	switch node.Kind {
+	case jsonnode.String:
+		return parseLeafRule(node)
+	case jsonnode.Object:
+		return parseContainerRule(node)
+	default:
+		return nil, jsonnode.Errorf(node.Pos, "rule must be a string or an object, is: %s", node.Kind)
+	}
+}
+
+func parseLeafRule(node *jsonnode.Node) (*Rule, error) {
+	kind, arg, hasArg := strings.Cut(node.Str, "=")
+	if !IsLeafKind(kind) {
+		return nil, jsonnode.Errorf(node.Pos, "unknown rule %q, want: int, string, bool, enum, ref", kind)
+	}
+	if (kind == "enum" || kind == "ref") && (!hasArg || arg == "") {
+		return nil, jsonnode.Errorf(node.Pos, "rule %q need an argument, like %s=xxx", kind, kind)
+	}
+	return &Rule{Kind: kind, Arg: arg, Pos: node.Pos}, nil
+}
+
+func parseContainerRule(node *jsonnode.Node) (*Rule, error) {
+	var rule *Rule
+	var options []jsonnode.Field // checks、switch、unique 这些跟在 list、map、struct 旁边的选项
+	for _, field := range node.Fields {
+		if _, ok := ruleOptionKinds[field.Key]; ok {
+			options = append(options, field)
+			continue
+		}
+		if rule != nil {
+			return nil, jsonnode.Errorf(field.KeyPos, "rule object must have exactly one of list, map, struct, got extra %q", field.Key)
+		}
+		var err error
+		switch field.Key {
+		case "list":
+			rule, err = parseListRule(field)
+		case "map":
+			rule, err = parseMapRule(field)
+		case "struct":
+			rule, err = parseStructRule(field)
+		default:
+			return nil, jsonnode.Errorf(field.KeyPos, "unknown rule %q, want: list, map, struct", field.Key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rule == nil {
+		return nil, jsonnode.Errorf(node.Pos, "empty rule object, want one of list, map, struct")
+	}
+	for _, field := range options {
+		kinds := ruleOptionKinds[field.Key]
+		if !slices.Contains(kinds, rule.Kind) {
+			return nil, jsonnode.Errorf(field.KeyPos, "%s can only be used with %s, is: %s", field.Key, strings.Join(kinds, ", "), rule.Kind)
+		}
+		var err error
+		switch field.Key {
+		case "checks":
+			rule.Checks, err = parseChecks(field.Value)
+		case "switch":
+			rule.Switch, err = parseSwitch(field)
+		case "unique":
+			rule.Unique, err = parseUnique(field.Value)
+		case "order":
+			rule.Orders, err = parseOrders(field)
+		case "sum", "min", "max", "count":
+			var aggregates []AggregateRule
+			aggregates, err = parseAggregates(field)
+			rule.Aggregates = append(rule.Aggregates, aggregates...)
+		case "sequence":
+			rule.Sequences, err = parseSequences(field)
+		case "required_keys":
+			rule.RequiredKeys, err = parseRequiredKeys(field.Value)
+			rule.RequiredKeysPos = field.Value.Pos
+		case "enum_keys":
+			if field.Value.Kind != jsonnode.String || field.Value.Str == "" {
+				err = jsonnode.Errorf(field.Value.Pos, "enum_keys must be an enum key, is: %s", field.Value.Kind)
+			}
+			rule.EnumKeys, rule.EnumKeysPos = field.Value.Str, field.Value.Pos
+		case "len":
+			if field.Value.Kind != jsonnode.String {
+				err = jsonnode.Errorf(field.Value.Pos, "len must be a range string like [1,10], is: %s", field.Value.Kind)
+			}
+			rule.Len, rule.LenPos = field.Value.Str, field.Value.Pos
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rule, nil
+}
+
+// 选项 => 可以跟在哪些规则旁边
+var ruleOptionKinds = map[string][]string{
+	"checks":        {"struct"},
+	"switch":        {"struct"},
+	"unique":        {"list", "map"},
+	"len":           {"list", "map"},
+	"order":         {"list"},
+	"sum":           {"list", "map"},
+	"min":           {"list", "map"},
+	"max":           {"list", "map"},
+	"count":         {"list", "map"},
+	"sequence":      {"list", "map"},
+	"required_keys": {"map"},
+	"enum_keys":     {"map"},
+}
+
+// 顺序的名字是否合法在创建 checker 的时候检测
+func parseOrders(field jsonnode.Field) ([]OrderRule, error) {
+	values, err := parseFieldValues(field, "order", `"Exp": "strict_asc"`)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]OrderRule, 0, len(values))
+	for _, v := range values {
+		orders = append(orders, OrderRule{Field: v.field, Order: v.value, Pos: v.pos})
+	}
+	return orders, nil
+}
+
+// 区间是否合法在创建 checker 的时候检测
+func parseAggregates(field jsonnode.Field) ([]AggregateRule, error) {
+	values, err := parseFieldValues(field, "range", `"Weight": "[10000,10000]"`)
+	if err != nil {
+		return nil, err
+	}
+	aggregates := make([]AggregateRule, 0, len(values))
+	for _, v := range values {
+		if field.Key == "count" && v.field != "" {
+			if _, err := fieldexpr.Parse(v.field); err != nil {
+				return nil, jsonnode.Errorf(v.keyPos, "count %q illegal: %v", v.field, err)
+			}
+		}
+		aggregates = append(aggregates, AggregateRule{Kind: field.Key, Arg: v.field, Range: v.value, Pos: v.pos})
+	}
+	return aggregates, nil
+}
+
+func parseSequences(field jsonnode.Field) ([]SequenceRule, error) {
+	values, err := parseFieldValues(field, "range", `"Level": "[1,-]"`)
+	if err != nil {
+		return nil, err
+	}
+	sequences := make([]SequenceRule, 0, len(values))
+	for _, v := range values {
+		sequences = append(sequences, SequenceRule{Field: v.field, Range: v.value, Pos: v.pos})
+	}
+	return sequences, nil
+}
+
+// 选项中的一项，字段为空时是元素本身
+type fieldValue struct {
+	field  string
+	value  string
+	keyPos jsonnode.Pos
+	pos    jsonnode.Pos
+}
+
+// 字符串时是元素本身，对象时是字段 => 字符串，如 "order": "asc"、"order": {"Exp": "strict_asc"}
+func parseFieldValues(field jsonnode.Field, what, example string) ([]fieldValue, error) {
+	node := field.Value
+	switch node.Kind {
+	case jsonnode.String:
+		return []fieldValue{{value: node.Str, keyPos: node.Pos, pos: node.Pos}}, nil
+	case jsonnode.Object:
+		if len(node.Fields) == 0 {
+			break
+		}
+		values := make([]fieldValue, 0, len(node.Fields))
+		for _, f := range node.Fields {
+			if f.Key == "" || f.Value.Kind != jsonnode.String {
+				return nil, jsonnode.Errorf(f.KeyPos, "%s must be field name => %s string like %s", field.Key, what, example)
+			}
+			values = append(values, fieldValue{field: f.Key, value: f.Value.Str, keyPos: f.KeyPos, pos: f.Value.Pos})
+		}
+		return values, nil
+	}
+	article := "a"
+	if strings.ContainsRune("aeiou", rune(what[0])) {
+		article = "an"
+	}
+	return nil, jsonnode.Errorf(node.Pos, "%s must be %s %s string or an object of field => %s, is: %s", field.Key, article, what, what, node.Kind)
+}
+
+// key 可以是字符串或整数，都按字符串比较
+func parseRequiredKeys(node *jsonnode.Node) ([]string, error) {
+	if node.Kind != jsonnode.Array || len(node.Elems) == 0 {
+		return nil, jsonnode.Errorf(node.Pos, "required_keys must be an array of keys, is: %s", node.Kind)
+	}
+	keys := make([]string, 0, len(node.Elems))
+	for _, elem := range node.Elems {
+		switch elem.Kind {
+		case jsonnode.String:
+			keys = append(keys, elem.Str)
+		case jsonnode.Number:
+			keys = append(keys, elem.Num.String())
+		default:
+			return nil, jsonnode.Errorf(elem.Pos, "required key must be a string or number, is: %s", elem.Kind)
+		}
+	}
+	return keys, nil
+}
+
+func parseUnique(node *jsonnode.Node) ([][]string, error) {
+	if node.Kind == jsonnode.Bool && node.Bool {
+		return [][]string{{}}, nil
+	}
+	if node.Kind != jsonnode.Array || len(node.Elems) == 0 {
+		return nil, jsonnode.Errorf(node.Pos, "unique must be true or an array of fields, is: %s", node.Kind)
+	}
+	uniques := make([][]string, 0, len(node.Elems))
+	for _, elem := range node.Elems {
+		var fields []string
+		switch elem.Kind {
+		case jsonnode.String:
+			fields = []string{elem.Str}
+		case jsonnode.Array:
+			for _, f := range elem.Elems {
+				if f.Kind != jsonnode.String {
+					return nil, jsonnode.Errorf(f.Pos, "unique field must be a string, is: %s", f.Kind)
+				}
+				fields = append(fields, f.Str)
+			}
+		}
+		if len(fields) == 0 || slices.Contains(fields, "") {
+			return nil, jsonnode.Errorf(elem.Pos, "unique must be a field name or an array of field names")
+		}
+		uniques = append(uniques, fields)
+	}
+	return uniques, nil
+}
+
+func parseChecks(node *jsonnode.Node) ([]string, error) {
+	if node.Kind != jsonnode.Array {
+		return nil, jsonnode.Errorf(node.Pos, "checks must be an array of expr, is: %s", node.Kind)
+	}
+	checks := make([]string, 0, len(node.Elems))
+	for _, elem := range node.Elems {
+		if elem.Kind != jsonnode.String {
+			return nil, jsonnode.Errorf(elem.Pos, "check must be a string expr, is: %s", elem.Kind)
+		}
+		if _, err := fieldexpr.Parse(elem.Str); err != nil {
+			return nil, jsonnode.Errorf(elem.Pos, "check %q illegal: %v", elem.Str, err)
+		}
+		checks = append(checks, elem.Str)
+	}
+	return checks, nil
+}
+
+func parseSwitch(field jsonnode.Field) (*SwitchRule, error) {
+	node := field.Value
+	if node.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(node.Pos, "switch must be an object with field and cases, is: %s", node.Kind)
+	}
+	sw := &SwitchRule{Pos: field.KeyPos}
+	for _, f := range node.Fields {
+		switch f.Key {
+		case "field":
+			if f.Value.Kind != jsonnode.String || f.Value.Str == "" {
+				return nil, jsonnode.Errorf(f.Value.Pos, "switch field must be a field name")
+			}
+			sw.Field = f.Value.Str
+		case "cases":
+			if f.Value.Kind != jsonnode.Object || len(f.Value.Fields) == 0 {
+				return nil, jsonnode.Errorf(f.Value.Pos, "switch cases must be an object of value => rule")
+			}
+			for _, c := range f.Value.Fields {
+				caseRule, err := ParseRule(c.Value)
+				if err != nil {
+					return nil, err
+				}
+				sw.Cases = append(sw.Cases, SwitchCase{Value: c.Key, Rule: caseRule, Pos: c.KeyPos})
+			}
+		case "default":
+			defaultRule, err := ParseRule(f.Value)
+			if err != nil {
+				return nil, err
+			}
+			sw.Default = defaultRule
+		default:
+			return nil, jsonnode.Errorf(f.KeyPos, "unknown switch key %q, want: field, cases, default", f.Key)
+		}
+	}
+	if sw.Field == "" || len(sw.Cases) == 0 {
+		return nil, jsonnode.Errorf(node.Pos, "switch need both field and cases")
+	}
+	return sw, nil
+}
+
+func parseListRule(field jsonnode.Field) (*Rule, error) {
+	elem, err := ParseRule(field.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{Kind: "list", Elem: elem, Pos: field.KeyPos}, nil
+}
+
+func parseMapRule(field jsonnode.Field) (*Rule, error) {
+	node := field.Value
+	if node.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(node.Pos, "map rule must be an object with key and value, is: %s", node.Kind)
+	}
+	rule := &Rule{Kind: "map", Pos: field.KeyPos}
+	for _, f := range node.Fields {
+		sub, err := ParseRule(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		switch f.Key {
+		case "key":
+			if !IsLeafKind(sub.Kind) {
+				return nil, jsonnode.Errorf(f.Value.Pos, "map key rule must be a basic rule, is: %s", sub.Kind)
+			}
+			rule.Key = sub
+		case "value":
+			rule.Elem = sub
+		default:
+			return nil, jsonnode.Errorf(f.KeyPos, "unknown map rule key %q, want: key, value", f.Key)
+		}
+	}
+	if rule.Key == nil || rule.Elem == nil {
+		return nil, jsonnode.Errorf(node.Pos, "map rule need both key and value")
+	}
+	return rule, nil
+}
+
+func parseStructRule(field jsonnode.Field) (*Rule, error) {
+	node := field.Value
+	if node.Kind != jsonnode.Object {
+		return nil, jsonnode.Errorf(node.Pos, "struct rule must be an object of fields, is: %s", node.Kind)
+	}
+	if len(node.Fields) == 0 {
+		return nil, jsonnode.Errorf(node.Pos, "struct rule has no field")
+	}
+	rule := &Rule{Kind: "struct", Pos: field.KeyPos}
+	for _, f := range node.Fields {
+		sub, err := ParseRule(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		rule.Fields = append(rule.Fields, FieldRule{Name: f.Key, Rule: sub})
+	}
+	return rule, nil
+}
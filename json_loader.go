@@ -0,0 +1,143 @@
+package valuerange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+	jsonnode "github.com/chenjinjie/value-range/internal/json-node"
+)
+
+// 从 json 加载一张配置表（或协议数据）
+// sample 决定解析成什么类型，如 []heroCfg(nil)，字段和 json 的对应规则和 encoding/json 一样
+// sample 为 nil 时解析成 map[string]any、[]any 这样的通用值，数字是 json.Number
+// 返回解析出来的值，同时也通过 LoadOneCfg 加载了进来，之后检测这张表时，不通过的值会带上在 json 中的行列，如 hero.json:12:15
+func (vr *ValueRange) LoadOneJSONCfg(cfgKey string, file string, r io.Reader, sample any) (any, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	root, err := jsonnode.Parse(file, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	valueType := reflect.TypeOf(sample)
+	if valueType == nil {
+		value = root.Interface()
+	} else {
+		ptr := reflect.New(valueType)
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return nil, jsonnode.Errorf(jsonnode.OffsetPos(file, data, int(typeErr.Offset)), "%s", err.Error())
+			}
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		value = ptr.Elem().Interface()
+	}
+
+	if !vr.LoadOneCfg(cfgKey, value) {
+		return nil, fmt.Errorf("%s: load cfg %s failed, duplicate key", file, cfgKey)
+	}
+	vr.locators[cfgKey] = &jsonLocator{root: root, rootType: valueType}
+	return value, nil
+}
+
+// 同 LoadOneJSONCfg，从文件中读取
+func (vr *ValueRange) LoadOneJSONFile(cfgKey string, path string, sample any) (any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return vr.LoadOneJSONCfg(cfgKey, path, f, sample)
+}
+
+// 把值路径转为 json 中的位置
+// 结构体字段名要转为 json 中的 key，所以和 Go 的类型一起往下走
+type jsonLocator struct {
+	root     *jsonnode.Node
+	rootType reflect.Type // 为 nil 时是通用值
+}
+
+func (jl *jsonLocator) Locate(path []basetyperange.PathSeg) string {
+	node, t := jl.root, jl.rootType
+	for _, seg := range path {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		var next *jsonnode.Node
+		switch {
+		case seg.IsKey:
+			next = node.Get(fmt.Sprint(seg.Key))
+			t = elemType(t)
+		case seg.Field == "":
+			if node.Kind == jsonnode.Array && seg.Index >= 0 && seg.Index < len(node.Elems) {
+				next = node.Elems[seg.Index]
+			}
+			t = elemType(t)
+		default:
+			name := seg.Field
+			if t != nil && t.Kind() == reflect.Struct {
+				if field, ok := t.FieldByName(seg.Field); ok {
+					name = jsonFieldName(field)
+					t = field.Type
+				} else {
+					t = nil
+				}
+			} else {
+				t = elemType(t)
+			}
+			next = getFold(node, name)
+		}
+		if next == nil { // 值在 json 中不存在，如缺少的字段，定位到上一层
+			break
+		}
+		node = next
+	}
+	return node.Pos.String()
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// 结构体字段在 json 中的 key，和 encoding/json 一样，优先用 tag
+func jsonFieldName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// 和 encoding/json 一样，key 先精确匹配，再忽略大小写匹配
+func getFold(node *jsonnode.Node, key string) *jsonnode.Node {
+	if value := node.Get(key); value != nil {
+		return value
+	}
+	if node.Kind != jsonnode.Object {
+		return nil
+	}
+	for _, field := range node.Fields {
+		if strings.EqualFold(field.Key, key) {
+			return field.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package valuerange
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// key 的大小写和字段名不一样也能对上，和 encoding/json 一样
+const heroJSON = `[
+	{"id": 61401, "quality": 1, "skins": [6140101, 6140102], "attrs": {"1": 100}},
+	{"id": 61402, "quality": 7, "skins": [6140201, 9], "attrs": {"1": 150, "3": 250}}
+]`
+
+func TestLoadOneJSONCfg(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList)
+	rows, err := valueRangeChecker.LoadOneJSONCfg(heroCfgKey, "hero.json", strings.NewReader(heroJSON), []heroCfg(nil))
+	if err != nil {
+		t.Fatalf("load hero.json failed: %v", err)
+	}
+	heroRows := rows.([]heroCfg)
+	if len(heroRows) != 2 || heroRows[1].Skins[1] != 9 || heroRows[1].Attrs[3] != 250 {
+		t.Fatalf("hero.json rows: %+v", heroRows)
+	}
+
+	if err := valueRangeChecker.LoadRuleFile("testdata/rules.json"); err != nil {
+		t.Fatalf("load rule file failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	var violations []Violation
+	for _, table := range report.Tables {
+		violations = append(violations, table.Violations...)
+	}
+	wantPos := []string{"hero.json:3:27", "hero.json:3:49", "hero.json:3:78"}
+	if len(violations) != len(wantPos) {
+		t.Fatalf("violations: %v", violations)
+	}
+	for i, want := range wantPos {
+		if violations[i].Pos != want {
+			t.Errorf("violation %d pos: %s, want: %s", i, violations[i].Pos, want)
+		}
+	}
+}
+
+func TestLoadOneJSONCfgAny(t *testing.T) {
+	value, err := ValueRangeChecker().LoadOneJSONCfg("hero", "hero.json", strings.NewReader(heroJSON), nil)
+	if err != nil {
+		t.Fatalf("load hero.json failed: %v", err)
+	}
+	rows, ok := value.([]any)
+	if !ok || len(rows) != 2 {
+		t.Fatalf("hero.json value: %#v", value)
+	}
+	row, _ := rows[1].(map[string]any)
+	if row["id"] != json.Number("61402") {
+		t.Errorf("hero.json row: %#v", rows[1])
+	}
+}
+
+func TestLoadOneJSONCfgMalformed(t *testing.T) {
+	cases := []struct {
+		json string
+		want string
+	}{
+		{"[\n\t{\"Id\": 1,}\n]", `hero.json:2:11: invalid character`},
+		{"[\n\t{\"Id\": -1}\n]", `hero.json:2:11: json: cannot unmarshal number -1`},
+		{`[{"Skins": "6140101"}]`, `hero.json:1:21: json: cannot unmarshal string`},
+	}
+	for _, c := range cases {
+		_, err := ValueRangeChecker().LoadOneJSONCfg(heroCfgKey, "hero.json", strings.NewReader(c.json), []heroCfg(nil))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("json: %q\nerr: %v\nwant: %s", c.json, err, c.want)
+		}
+	}
+}
+
+// 动态协议字段，json.Unmarshal 到 any 中
+type skillParamChecker struct {
+	Level ValueRangerChecker
+	Name  ValueRangerChecker
+}
+
+func TestCheckUntypedMap(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	newChecker := func(opts MapKeyOptions) ValueRangerChecker {
+		return valueRangeChecker.StructValueRangerCheckerWithOptions(skillParamChecker{
+			Level: valueRangeChecker.IntValueRangerChecker("[1,10]"),
+			Name:  valueRangeChecker.StringValueRangerChecker(""),
+		}, opts)
+	}
+	unmarshal := func(data string, useNumber bool) any {
+		dec := json.NewDecoder(strings.NewReader(data))
+		if useNumber {
+			dec.UseNumber()
+		}
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			t.Fatalf("decode %s failed: %v", data, err)
+		}
+		return value
+	}
+
+	cases := []struct {
+		data string
+		opts MapKeyOptions
+		want bool
+	}{
+		{`{"Level": 3, "Name": "fire"}`, MapKeyOptions{JSONNumber: true}, true},
+		{`{"Level": 3, "Name": "fire"}`, MapKeyOptions{}, false}, // 不转的话 json 的数字不是 int
+		{`{"Level": 11, "Name": "fire"}`, MapKeyOptions{JSONNumber: true}, false},
+		{`{"Level": 3.5, "Name": "fire"}`, MapKeyOptions{JSONNumber: true}, false},
+		{`{"Level": 3}`, MapKeyOptions{JSONNumber: true}, false},
+		{`{"Level": 3}`, MapKeyOptions{AllowMissing: true, JSONNumber: true}, true},
+		{`{"Level": 3, "Name": "fire", "Cd": 2}`, MapKeyOptions{JSONNumber: true}, true},
+		{`{"Level": 3, "Name": "fire", "Cd": 2}`, MapKeyOptions{RejectExtra: true, JSONNumber: true}, false},
+		{`{"Level": "3", "Name": "fire"}`, MapKeyOptions{JSONNumber: true}, false},
+	}
+	for _, c := range cases {
+		for _, useNumber := range []bool{false, true} {
+			if got := newChecker(c.opts).Check(unmarshal(c.data, useNumber)); got != c.want {
+				t.Errorf("data: %s, opts: %+v, useNumber: %v, check: %v, want: %v", c.data, c.opts, useNumber, got, c.want)
+			}
+		}
+	}
+
+	valueRangeChecker.RegChecker("skillParam", newChecker(MapKeyOptions{RejectExtra: true, JSONNumber: true}))
+	violations := valueRangeChecker.CheckWithReport("skillParam", unmarshal(`{"Level": 0, "Name": "fire", "Cd": 2}`, false))
+	if len(violations) != 2 || violations[0].Path != "skillParam.Level" || violations[1].Path != "skillParam.Cd" {
+		t.Errorf("violations: %v", violations)
+	}
+
+	// 加载 json 时不传 sample，得到的就是这样的通用值
+	value, err := valueRangeChecker.LoadOneJSONCfg("skill", "skill.json", strings.NewReader(`[{"Level": 3, "Name": "fire"}]`), nil)
+	if err != nil || !valueRangeChecker.ListValueRangerChecker(newChecker(MapKeyOptions{JSONNumber: true})).Check(value) {
+		t.Errorf("untyped json value check failed: %#v, %v", value, err)
+	}
+
+	// enum、ref 也一样，嵌套的 []any 中的数字也会转
+	valueRangeChecker.LoadOneEnumCfg("element", map[uint64]struct{}{1: {}, 2: {}})
+	valueRangeChecker.LoadOneCfg("buffCfg", []struct{ Id uint32 }{{Id: 101}})
+	elementChecker := valueRangeChecker.StructValueRangerCheckerWithOptions(struct{ Element, Buffs ValueRangerChecker }{
+		Element: valueRangeChecker.EnumValueRangerChecker("element"),
+		Buffs:   valueRangeChecker.ListValueRangerChecker(valueRangeChecker.RefValueRangerChecker("buffCfg.Id")),
+	}, MapKeyOptions{JSONNumber: true})
+	for _, useNumber := range []bool{false, true} {
+		if !elementChecker.Check(unmarshal(`{"Element": 2, "Buffs": [101]}`, useNumber)) {
+			t.Errorf("useNumber: %v, enum and ref should pass", useNumber)
+		}
+		if elementChecker.Check(unmarshal(`{"Element": 3, "Buffs": [101]}`, useNumber)) || elementChecker.Check(unmarshal(`{"Element": 2, "Buffs": [102]}`, useNumber)) {
+			t.Errorf("useNumber: %v, enum and ref should fail", useNumber)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+type chestCfg struct {
+	Id    uint64
+	Slots []uint64          `vr:"list=[3,3],ref=itemCfg.Id"`
+	Attrs map[uint32]uint32 `vr:"map=[0,2],int=(0,-)"`
+	Tags  []string          `vr:"list=[1,-]"`
+}
+
+type chestCfgChecker struct {
+	Id    ValueRangerChecker
+	Slots ValueRangerChecker
+	Attrs ValueRangerChecker
+	Tags  ValueRangerChecker
+}
+
+func TestLen(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.StructValueRangerChecker(chestCfgChecker{
+			Id:    valueRangeChecker.IntValueRangerChecker(""),
+			Slots: valueRangeChecker.WithLen(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.RefValueRangerChecker("itemCfg.Id")), "[3,3]"),
+			Attrs: valueRangeChecker.WithLen(valueRangeChecker.MapValueRangerChecker(valueRangeChecker.IntValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker("(0,-)")), "[0,2]"),
+			Tags:  valueRangeChecker.WithLen(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StringValueRangerChecker("")), "[1,-]"),
+		})
+	}
+
+	good := chestCfg{Id: 1, Slots: []uint64{1001, 1002, 1001}, Attrs: map[uint32]uint32{1: 10}, Tags: []string{"gold"}}
+	bad := chestCfg{Id: 2, Slots: []uint64{1001, 9}, Attrs: map[uint32]uint32{1: 10, 2: 20, 3: 30}}
+	wants := []string{
+		".Slots: length 2 not match len[3,3]",
+		".Slots[1]: value 9 (uint64) not match ref(itemCfg.Id)",
+		".Attrs: length 3 not match len[0,2]",
+		".Tags: length 0 not match len[1,-]", // nil slice 当作空的
+	}
+	checkBothPaths(t, valueRangeChecker, "chest", newChecker, chestCfg{}, good)
+	checkBothPaths(t, valueRangeChecker, "chest", nil, nil, bad, wants...)
+
+	// tag 生成的和手写的一样
+	valueRangeChecker.RegChecker("tagChest", valueRangeChecker.TagValueRangerChecker(chestCfg{}))
+	if !valueRangeChecker.Check("tagChest", good) || valueRangeChecker.Check("tagChest", bad) {
+		t.Errorf("tagChest check wrong")
+	}
+	violations := valueRangeChecker.CheckWithReport("tagChest", bad)
+	if len(violations) != len(wants) {
+		t.Fatalf("tagChest violations: %v", violations)
+	}
+	for i, want := range wants {
+		if violations[i].String() != "tagChest"+want {
+			t.Errorf("tagChest violation %d: %s, want: %s", i, violations[i].String(), "tagChest"+want)
+		}
+	}
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.WithLen(valueRangeChecker.IntValueRangerChecker(""), "[1,-]") },
+		func() {
+			valueRangeChecker.WithLen(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("")), "[1,")
+		},
+		func() {
+			valueRangeChecker.TagValueRangerChecker(struct {
+				Ids []int `vr:"list="`
+			}{})
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestLenRuleFile(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	valueRangeChecker.LoadOneCfg("chestCfg", []chestCfg{
+		{Id: 1, Slots: []uint64{1001, 1002, 1001}},
+		{Id: 2, Slots: []uint64{1001}},
+	})
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"chestCfg": {"list": {"struct": {"Slots": {"list": "ref=itemCfg.Id", "len": "[3,3]"}}}, "len": "[1,10]"}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	violations := report.Tables[0].Violations
+	if len(violations) != 1 || violations[0].String() != "chestCfg[1].Slots: length 1 not match len[3,3]" {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"list": "int", "len": "[5,1]"}}}`, `rules.json:1:41: len IntRange max value less than min value`},
+		{`{"tables": {"a": {"list": "int", "len": 3}}}`, `rules.json:1:41: len must be a range string like [1,10]`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "len": "[1,-]"}}}`, `rules.json:1:44: len can only be used with list, map, is: struct`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
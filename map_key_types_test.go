@@ -0,0 +1,156 @@
+package valuerange
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+type gridPos struct {
+	X int32 `vr:"int=[0,9]"`
+	Y int32 `vr:"int=[0,9]"`
+}
+
+type gridCfg struct {
+	Blocks  map[gridPos]string  `vr:"map,string"`
+	Corners map[[2]uint8]uint32 `vr:"map,int=[1,-]"`
+	Flags   map[bool]int        `vr:"map,int=[0,1]"`
+}
+
+func TestMapKeyTypes(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	posChecker := func() ValueRangerChecker {
+		return valueRangeChecker.StructValueRangerChecker(struct{ X, Y ValueRangerChecker }{
+			X: valueRangeChecker.IntValueRangerChecker("[0,9]"),
+			Y: valueRangeChecker.IntValueRangerChecker("[0,9]"),
+		})
+	}
+	newCheckers := map[string]func() (ValueRangerChecker, any){
+		"struct": func() (ValueRangerChecker, any) {
+			return valueRangeChecker.MapValueRangerChecker(posChecker(), valueRangeChecker.StringValueRangerChecker("")), map[gridPos]string{}
+		},
+		"array": func() (ValueRangerChecker, any) {
+			return valueRangeChecker.MapValueRangerChecker(
+				valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[0,3]")), valueRangeChecker.IntValueRangerChecker(""),
+			), map[[2]uint8]uint32{}
+		},
+		"bool": func() (ValueRangerChecker, any) {
+			return valueRangeChecker.MapValueRangerChecker(valueRangeChecker.BoolValueRangerChecker("true"), valueRangeChecker.IntValueRangerChecker("")), map[bool]int{}
+		},
+		"float": func() (ValueRangerChecker, any) {
+			return valueRangeChecker.MapValueRangerChecker(valueRangeChecker.FuncValueRangerChecker("[1,3]", func(value any) error {
+				if f := value.(float64); !(f >= 1 && f <= 3) { // NaN 也不通过
+					return fmt.Errorf("%v out of range", f)
+				}
+				return nil
+			}), valueRangeChecker.IntValueRangerChecker("")), map[float64]int{}
+		},
+	}
+	for name, newChecker := range newCheckers {
+		checker, sample := newChecker()
+		valueRangeChecker.RegChecker(name, checker)
+		checker, _ = newChecker()
+		valueRangeChecker.RegChecker("compiled_"+name, valueRangeChecker.CompileChecker(checker, sample))
+	}
+
+	cases := []struct {
+		key   string
+		value any
+		want  string // 为空时要通过
+	}{
+		{"struct", map[gridPos]string{{X: 1, Y: 2}: "a"}, ""},
+		{"struct", map[gridPos]string{{X: 1, Y: 20}: "a"}, "struct[{1 20}].Y: value 20 (int32) not match int[0,9]"},
+		{"array", map[[2]uint8]uint32{{0, 3}: 1}, ""},
+		{"array", map[[2]uint8]uint32{{0, 5}: 1}, "array[[0 5]][1]: value 5 (uint8) not match int[0,3]"},
+		{"bool", map[bool]int{true: 1}, ""},
+		{"bool", map[bool]int{false: 1}, "bool[false]: value false (bool) not match bool=true"},
+		{"float", map[float64]int{1: 1, 2.5: 3}, ""},
+		{"float", map[float64]int{3.5: 1}, "float[3.5]: func([1,3]): 3.5 out of range"},
+	}
+	for _, c := range cases {
+		for _, key := range []string{c.key, "compiled_" + c.key} {
+			violations := valueRangeChecker.CheckWithReport(key, c.value)
+			if c.want == "" {
+				if len(violations) != 0 || !valueRangeChecker.Check(key, c.value) {
+					t.Errorf("%s %v violations: %v", key, c.value, violations)
+				}
+				continue
+			}
+			want := key + c.want[len(c.key):]
+			if len(violations) != 1 || violations[0].String() != want {
+				t.Errorf("%s %v violations: %v, want: %s", key, c.value, violations, want)
+			}
+			if valueRangeChecker.Check(key, c.value) {
+				t.Errorf("%s %v should fail", key, c.value)
+			}
+		}
+	}
+
+	// NaN 的 key 不等于自己，不能用 MapIndex 取值
+	nanMap := map[float64]int{math.NaN(): 1, math.NaN(): 2, 2: 3}
+	for _, key := range []string{"float", "compiled_float"} {
+		violations := valueRangeChecker.CheckWithReport(key, nanMap)
+		want := key + "[NaN]: func([1,3]): NaN out of range"
+		if len(violations) != 2 || violations[0].String() != want || violations[1].String() != want {
+			t.Errorf("%s NaN violations: %v, want 2 of: %s", key, violations, want)
+		}
+		if valueRangeChecker.Check(key, nanMap) {
+			t.Errorf("%s NaN should fail", key)
+		}
+	}
+	newNaNChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithAggregates(valueRangeChecker.MapValueRangerChecker(
+			valueRangeChecker.AnyValueRangerChecker(), valueRangeChecker.IntValueRangerChecker("[1,3]"),
+		), valueRangeChecker.SumAggregate("", "[6,6]"))
+	}
+	valueRangeChecker.RegChecker("nanSum", newNaNChecker())
+	valueRangeChecker.RegChecker("compiledNaNSum", valueRangeChecker.CompileChecker(newNaNChecker(), nanMap))
+	for _, key := range []string{"nanSum", "compiledNaNSum"} {
+		if violations := valueRangeChecker.CheckWithReport(key, nanMap); len(violations) != 0 || !valueRangeChecker.Check(key, nanMap) {
+			t.Errorf("%s violations: %v", key, violations)
+		}
+	}
+
+	// tag 中结构体、数组的 key 按它自己的类型和字段的 vr tag 生成
+	valueRangeChecker.RegChecker("grid", valueRangeChecker.TagValueRangerChecker(gridCfg{}))
+	good := gridCfg{
+		Blocks:  map[gridPos]string{{X: 1, Y: 1}: "wall"},
+		Corners: map[[2]uint8]uint32{{0, 9}: 1},
+		Flags:   map[bool]int{true: 1, false: 0},
+	}
+	if violations := valueRangeChecker.CheckWithReport("grid", good); len(violations) != 0 {
+		t.Errorf("grid good violations: %v", violations)
+	}
+	bad := gridCfg{Blocks: map[gridPos]string{{X: 10, Y: 1}: "wall"}, Flags: map[bool]int{true: 2}}
+	wants := []string{
+		"grid.Blocks[{10 1}].X: value 10 (int32) not match int[0,9]",
+		"grid.Flags[true]: value 2 (int) not match int[0,1]",
+	}
+	violations := valueRangeChecker.CheckWithReport("grid", bad)
+	if len(violations) != len(wants) {
+		t.Fatalf("grid bad violations: %v", violations)
+	}
+	for i, want := range wants {
+		if violations[i].String() != want {
+			t.Errorf("grid violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	for _, sample := range []any{
+		struct {
+			Blocks map[gridPos]string `vr:"map,key:int,string"`
+		}{},
+		struct {
+			Blocks map[[2]any]string `vr:"map,string"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%T should panic", sample)
+				}
+			}()
+			valueRangeChecker.TagValueRangerChecker(sample)
+		}()
+	}
+}
@@ -0,0 +1,104 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+type heroStatCfg struct {
+	Id    uint64
+	Attrs map[uint32]uint32
+	Stats map[string]int
+}
+
+type heroStatCfgChecker struct {
+	Id    ValueRangerChecker
+	Attrs ValueRangerChecker
+	Stats ValueRangerChecker
+}
+
+func TestMapKeys(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneEnumCfg(enumHeroCfgAttr, map[uint64]struct{}{
+		uint64(heroCfgAttr_hp): {}, uint64(heroCfgAttr_mp): {},
+	})
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.StructValueRangerChecker(heroStatCfgChecker{
+			Id: valueRangeChecker.IntValueRangerChecker(""),
+			Attrs: valueRangeChecker.WithEnumKeys(valueRangeChecker.MapValueRangerChecker(
+				valueRangeChecker.IntValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker(""),
+			), enumHeroCfgAttr),
+			Stats: valueRangeChecker.WithRequiredKeys(valueRangeChecker.MapValueRangerChecker(
+				valueRangeChecker.StringValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker(""),
+			), "hp", "mp"),
+		})
+	}
+
+	good := heroStatCfg{Id: 1, Attrs: map[uint32]uint32{1: 100, 2: 50}, Stats: map[string]int{"hp": 1, "mp": 2, "atk": 3}}
+	bad := heroStatCfg{Id: 2, Attrs: map[uint32]uint32{1: 100, 9: 1, 10: 1}, Stats: map[string]int{"mp": 2}}
+	checkBothPaths(t, valueRangeChecker, "heroStat", newChecker, heroStatCfg{}, good)
+	checkBothPaths(t, valueRangeChecker, "heroStat", nil, nil, bad,
+		".Attrs: keys enum(heroCfgAttr) missing: 2, extra: 10, 9",
+		".Stats: keys required(hp,mp) missing: hp",
+	)
+	// nil map 当作空的
+	checkBothPaths(t, valueRangeChecker, "heroStat", nil, nil, heroStatCfg{Id: 3},
+		".Attrs: keys enum(heroCfgAttr) missing: 1, 2",
+		".Stats: keys required(hp,mp) missing: hp, mp",
+	)
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.WithRequiredKeys(valueRangeChecker.IntValueRangerChecker(""), "hp") },
+		func() {
+			valueRangeChecker.WithRequiredKeys(valueRangeChecker.MapValueRangerChecker(valueRangeChecker.StringValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker("")))
+		},
+		func() {
+			valueRangeChecker.WithEnumKeys(valueRangeChecker.MapValueRangerChecker(valueRangeChecker.IntValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker("")), "noEnum")
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestMapKeysRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	_, err := valueRangeChecker.LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(heroCSV), heroCfg{}, CSVOptions{ListSep: "|", KVSep: "="})
+	if err != nil {
+		t.Fatalf("load hero.csv failed: %v", err)
+	}
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"enums": {"heroCfgAttr": [1, 2]}, "tables": {
+		"heroCfg": {"list": {"struct": {"Attrs": {"map": {"key": "int", "value": "int"}, "enum_keys": "heroCfgAttr", "required_keys": [1]}}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	violations := report.Tables[0].Violations
+	if len(violations) != 1 || violations[0].String() != "hero.csv:3:G: heroCfg[1].Attrs: keys enum(heroCfgAttr) missing: 2, extra: 3" {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"map": {"key": "int", "value": "int"}, "enum_keys": "noEnum"}}}`, `rules.json:1:71: WithEnumKeys enumKey not exit: noEnum`},
+		{`{"tables": {"a": {"map": {"key": "int", "value": "int"}, "enum_keys": 1}}}`, `rules.json:1:71: enum_keys must be an enum key, is: number`},
+		{`{"tables": {"a": {"map": {"key": "int", "value": "int"}, "required_keys": []}}}`, `rules.json:1:75: required_keys must be an array of keys, is: array`},
+		{`{"tables": {"a": {"map": {"key": "int", "value": "int"}, "required_keys": [true]}}}`, `rules.json:1:76: required key must be a string or number, is: bool`},
+		{`{"tables": {"a": {"list": "int", "required_keys": ["hp"]}}}`, `rules.json:1:34: required_keys can only be used with map, is: list`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
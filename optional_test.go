@@ -0,0 +1,122 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// 活动配置，等级、标签可以不填，奖励必须填（可以是空的）
+type activityCfg struct {
+	Id      uint64
+	Level   *int
+	Rewards []uint64
+	Tag     *heroTagCfg
+}
+
+type activityCfgChecker struct {
+	Id      ValueRangerChecker
+	Level   ValueRangerChecker
+	Rewards ValueRangerChecker
+	Tag     ValueRangerChecker
+}
+
+// tag 写法的活动配置
+type activityTagCfg struct {
+	Id      uint64      `vr:"int=[1,-]"`
+	Level   *int        `vr:"optional,int=[1,5]"`
+	Rewards []uint64    `vr:"required,list,int=[1,-]"`
+	Tag     *heroTagCfg `vr:"optional"`
+	Boss    *int        `vr:"int=[1,5]"` // 没有写 optional，nil 不通过
+}
+
+func TestOptionalChecker(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	checker := valueRangeChecker.StructValueRangerChecker(activityCfgChecker{
+		Id:      valueRangeChecker.IntValueRangerChecker("[1,-]"),
+		Level:   valueRangeChecker.OptionalValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[1,5]")),
+		Rewards: valueRangeChecker.RequiredValueRangerChecker(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[1,-]"))),
+		Tag: valueRangeChecker.OptionalValueRangerChecker(valueRangeChecker.StructValueRangerChecker(heroTagCfgChecker{
+			Free: valueRangeChecker.BoolValueRangerChecker(""),
+		})),
+	})
+	compiled := valueRangeChecker.CompileChecker(checker, activityCfg{})
+
+	level3, level9 := 3, 9
+	cases := []struct {
+		cfg  activityCfg
+		want bool
+	}{
+		{activityCfg{Id: 1, Rewards: []uint64{}}, true},
+		{activityCfg{Id: 1, Level: &level3, Rewards: []uint64{1}, Tag: &heroTagCfg{Free: true}}, true},
+		{activityCfg{Id: 1, Level: &level9, Rewards: []uint64{1}}, false},
+		{activityCfg{Id: 1}, false}, // 必填的奖励没有填
+	}
+	for _, c := range cases {
+		if got := checker.Check(c.cfg); got != c.want {
+			t.Errorf("cfg: %+v, check: %v, want: %v", c.cfg, got, c.want)
+		}
+		if got := compiled.Check(c.cfg); got != c.want {
+			t.Errorf("cfg: %+v, compiled check: %v, want: %v", c.cfg, got, c.want)
+		}
+	}
+
+	valueRangeChecker.RegChecker("activityCfg", checker)
+	violations := valueRangeChecker.CheckWithReport("activityCfg", activityCfg{Id: 1})
+	if len(violations) != 1 || violations[0].Path != "activityCfg.Rewards" || !strings.Contains(violations[0].Msg, "required") {
+		t.Errorf("violations: %v", violations)
+	}
+}
+
+// nil 不再 panic，而是检测不通过并指出位置
+func TestNilValue(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	tagChecker := valueRangeChecker.StructValueRangerChecker(heroTagCfgChecker{
+		Free: valueRangeChecker.BoolValueRangerChecker(""),
+	})
+	valueRangeChecker.RegChecker("tags", valueRangeChecker.ListValueRangerChecker(tagChecker))
+
+	violations := valueRangeChecker.CheckWithReport("tags", []*heroTagCfg{{Free: true}, nil})
+	if len(violations) != 1 || violations[0].Path != "tags[1]" || !strings.Contains(violations[0].Msg, "nil ptr") {
+		t.Errorf("violations: %v", violations)
+	}
+	for _, value := range []any{nil, (*heroTagCfg)(nil)} {
+		if tagChecker.Check(value) {
+			t.Errorf("nil value %#v should not pass", value)
+		}
+	}
+	if valueRangeChecker.ListValueRangerChecker(tagChecker).Check(nil) {
+		t.Errorf("nil list should not pass")
+	}
+}
+
+func TestTagOptional(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	checker := valueRangeChecker.TagValueRangerChecker(activityTagCfg{})
+	valueRangeChecker.RegChecker("activityTagCfg", checker)
+
+	boss, level9 := 1, 9
+	if violations := valueRangeChecker.CheckWithReport("activityTagCfg", activityTagCfg{Id: 1, Rewards: []uint64{}, Boss: &boss}); len(violations) != 0 {
+		t.Errorf("violations: %v", violations)
+	}
+	violations := valueRangeChecker.CheckWithReport("activityTagCfg", activityTagCfg{Id: 1, Level: &level9})
+	wantPaths := []string{"activityTagCfg.Level", "activityTagCfg.Rewards", "activityTagCfg.Boss"}
+	if len(violations) != len(wantPaths) {
+		t.Fatalf("violations: %v", violations)
+	}
+	for i, want := range wantPaths {
+		if violations[i].Path != want {
+			t.Errorf("violation %d path: %s, want: %s", i, violations[i].Path, want)
+		}
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(r.(string), `duplicate "optional" and "required"`) {
+				t.Errorf("recover: %v", r)
+			}
+		}()
+		valueRangeChecker.TagValueRangerChecker(struct {
+			Level *int `vr:"optional,required"`
+		}{})
+	}()
+}
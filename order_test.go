@@ -0,0 +1,142 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+type levelCfg struct {
+	Level   int
+	Exp     uint64
+	Weights []uint32
+}
+
+const levelCSV = `Level,Exp,Weights
+1,100,50|30|20
+2,120,50|50|10
+3,120,10|20
+5,300,9
+`
+
+func TestOrderRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	_, err := valueRangeChecker.LoadOneCSVCfg("levelCfg", "level.csv", strings.NewReader(levelCSV), levelCfg{}, CSVOptions{ListSep: "|"})
+	if err != nil {
+		t.Fatalf("load level.csv failed: %v", err)
+	}
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"levelCfg": {"list": {"struct": {"Level": "int", "Exp": "int", "Weights": {"list": "int", "order": "desc"}}}, "order": {"Level": "strict_asc", "Exp": "strict_asc"}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+
+	// 每一个和前一行顺序不对的行都记一条，带上前一行的位置
+	report := valueRangeChecker.CheckAll()
+	wants := []string{
+		"level.csv:4:C: levelCfg[2].Weights[1]: order desc broken, value 20 after value 10 at levelCfg[2].Weights[0] (level.csv:4:C)",
+		"level.csv:4:B: levelCfg[2].Exp: order strict_asc broken, Exp=120 after Exp=120 at levelCfg[1].Exp (level.csv:3:B)",
+	}
+	violations := report.Tables[0].Violations
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if violations[i].String() != want {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"list": "int", "order": "up"}}}`, `rules.json:1:43: unknown order "up", want one of: asc, strict_asc, desc, strict_desc`},
+		{`{"tables": {"a": {"list": "int", "order": {"Exp": 1}}}}`, `rules.json:1:44: order must be field name => order string`},
+		{`{"tables": {"a": {"list": "int", "order": []}}}`, `rules.json:1:43: order must be an order string or an object of field => order, is: array`},
+		{`{"tables": {"a": {"map": {"key": "int", "value": "int"}, "order": "asc"}}}`, `rules.json:1:58: order can only be used with list, is: map`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
+
+// 只检测顺序，元素的值都通过
+type anyValueChecker struct{}
+
+func (anyValueChecker) Check(value any) bool { return true }
+func (anyValueChecker) ToString() string     { return "any" }
+
+func TestOrder(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithOrder(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.StructValueRangerChecker(rewardCfgChecker{
+			Id:   valueRangeChecker.IntValueRangerChecker(""),
+			Type: valueRangeChecker.StringValueRangerChecker(""),
+		})), OrderStrictDesc, "Id")
+	}
+
+	// nil 的行不参与比较，下一行和再前面的行比
+	rewards := []*rewardCfg{{Id: 3, Type: rewardType_exp}, nil, {Id: 3, Type: rewardType_exp}, {Id: 1, Type: rewardType_exp}}
+	checkBothPaths(t, valueRangeChecker, "reward", newChecker, []*rewardCfg{}, rewards,
+		"[1]: value is nil ptr, type: *valuerange.rewardCfg",
+		"[1].Id: order(Id strict_desc) error: field Id is nil",
+		"[2].Id: order strict_desc broken, Id=3 after Id=3 at {key}[0].Id",
+	)
+	checkBothPaths(t, valueRangeChecker, "reward", nil, nil, []*rewardCfg{{Id: 3}, {Id: 2}, {Id: 1}})
+
+	// 元素本身，字符串按字典序，自定义的整数类型按底层类型
+	type quality int
+	orders := []struct {
+		order Order
+		value any
+		pass  bool
+	}{
+		{OrderAsc, []string{"a", "b", "b"}, true},
+		{OrderStrictAsc, []string{"a", "b", "b"}, false},
+		{OrderDesc, []quality{3, 3, 1}, true},
+		{OrderAsc, []float64{0.1, 0.5, 0.2}, false},
+		{OrderStrictAsc, []any{1, uint64(2), 2.5}, true},
+		{OrderAsc, []any{1, "2"}, false},
+	}
+	for i, o := range orders {
+		checker := valueRangeChecker.WithOrder(valueRangeChecker.ListValueRangerChecker(anyValueChecker{}), o.order, "")
+		if checker.Check(o.value) != o.pass {
+			t.Errorf("order %d %s %v should pass: %v", i, o.order, o.value, o.pass)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("WithOrder on map checker should panic")
+		}
+	}()
+	valueRangeChecker.WithOrder(valueRangeChecker.MapValueRangerChecker(valueRangeChecker.IntValueRangerChecker(""), valueRangeChecker.IntValueRangerChecker("")), OrderAsc, "")
+}
+
+// 没有 sample 加载的 json 中的数字是 json.Number，要按数字比较
+func TestOrderJSON(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	_, err := valueRangeChecker.LoadOneJSONCfg("levelCfg", "level.json", strings.NewReader(`[{"Exp": 9}, {"Exp": 10}, {"Exp": 10}, {"Exp": 9.5}]`), nil)
+	if err != nil {
+		t.Fatalf("load level.json failed: %v", err)
+	}
+	valueRangeChecker.RegChecker("levelCfg", valueRangeChecker.WithOrder(valueRangeChecker.ListValueRangerChecker(anyValueChecker{}), OrderStrictAsc, "Exp"))
+	report := valueRangeChecker.CheckAll()
+	violations := report.Tables[0].Violations
+	wants := []string{
+		"level.json:1:35: levelCfg[2].Exp: order strict_asc broken, Exp=10 after Exp=10 at levelCfg[1].Exp (level.json:1:22)",
+		"level.json:1:48: levelCfg[3].Exp: order strict_asc broken, Exp=9.5 after Exp=10 at levelCfg[2].Exp (level.json:1:35)",
+	}
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if violations[i].String() != want {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package valuerange
+
+import (
+	"errors"
+	"fmt"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+	expandtyperange "github.com/chenjinjie/value-range/internal/expand-type-range"
+)
+
+// 给加载过的配置表声明主键，配置表是列表或 map，行是结构体，主键是 int、uint、string 字段
+// 主键必须是唯一的，有重复时返回的错误中列出每一个重复的行和第一次出现的行，这时不会建立索引
+// 声明之后：
+//  1. LookupRow 可以按主键找到一行
+//  2. ref 这张表的主键时直接用索引中的值
+//  3. CheckAll 的路径中用主键表示行，如 heroCfg[Id=61403].Skins
+func (vr *ValueRange) SetPrimaryKey(cfgKey, keyField string) error {
+	return vr.primaryKeyError(cfgKey, keyField, vr.refStore.SetPrimaryKey(cfgKey, keyField))
+}
+
+// 主键重复时，错误中列出每一个重复的行
+func (vr *ValueRange) primaryKeyError(cfgKey, keyField string, err error) error {
+	if !errors.Is(err, expandtyperange.ErrPrimaryKeyDuplicate) {
+		return err
+	}
+	data, _ := vr.refStore.OriData(cfgKey)
+	report := basetyperange.NewReport(cfgKey)
+	report.Locator = vr.locators[cfgKey]
+	basetyperange.CheckUnique(data, report, keyField)
+	return fmt.Errorf("%s primary key %s duplicate:\n%s", cfgKey, keyField, report.String())
+}
+
+// 同 LoadOneCfg，再用 keyField 作为主键，见 SetPrimaryKey
+// 主键有问题时数据还是加载了的，只是没有主键
+func (vr *ValueRange) LoadOneCfgWithKey(cfgKey string, cfgData any, keyField string) error {
+	if !vr.LoadOneCfg(cfgKey, cfgData) {
+		return fmt.Errorf("load cfg failed, key: %s", cfgKey)
+	}
+	return vr.SetPrimaryKey(cfgKey, keyField)
+}
+
+// 按主键找到一行，主键的值 int 的 1 和 uint64 的 1 是同一个，没有声明主键、找不到时返回 false
+func (vr *ValueRange) LookupRow(cfgKey string, primaryKey any) (any, bool) {
+	return vr.refStore.LookupRow(cfgKey, primaryKey)
+}
@@ -0,0 +1,147 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrimaryKey(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	if err := valueRangeChecker.LoadOneCfgWithKey(heroSkinCfgKey, heroSkinCfgList, "Id"); err != nil {
+		t.Fatalf("load heroSkinCfg failed: %v", err)
+	}
+	if _, err := valueRangeChecker.LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(heroCSV), heroCfg{}, CSVOptions{ListSep: "|", KVSep: "="}); err != nil {
+		t.Fatalf("load hero.csv failed: %v", err)
+	}
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"heroCfg":     {"key": "Id", "list": {"struct": {"Id": "int", "Skins": {"list": "ref=heroSkinCfg.Id"}}}},
+		"heroSkinCfg": {"key": "Id", "list": {"struct": {"Id": "int"}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+
+	// 路径中用主键表示行，数据来源的位置不变
+	report := valueRangeChecker.CheckAll()
+	if len(report.Tables) != 2 || len(report.Tables[0].Violations) != 1 || !report.Tables[1].Pass() {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	if got := report.Tables[0].Violations[0].String(); !strings.HasPrefix(got, "hero.csv:3:F: heroCfg[Id=61402].Skins[1]: value 9") {
+		t.Errorf("violation: %s", got)
+	}
+	// 不是加载进来的那份数据时，还是用下标
+	if violations := valueRangeChecker.CheckWithReport(heroCfgKey, []heroCfg{{Id: 1, Skins: []uint64{9}}}); len(violations) != 1 || !strings.HasPrefix(violations[0].String(), "heroCfg[0].Skins[0]") {
+		t.Errorf("violations: %v", violations)
+	}
+
+	row, ok := valueRangeChecker.LookupRow(heroCfgKey, 61402)
+	if !ok || row.(heroCfg).Desc != "ace" {
+		t.Errorf("lookup row: %v, %v", row, ok)
+	}
+	if _, ok := valueRangeChecker.LookupRow(heroCfgKey, uint64(9)); ok {
+		t.Errorf("lookup row 9 should not exist")
+	}
+	if _, ok := valueRangeChecker.LookupRow("noCfg", 1); ok {
+		t.Errorf("lookup row in table without primary key should fail")
+	}
+
+	// map 的配置表，行是指针
+	rewards := map[uint64]*rewardCfg{1: {Id: 101, Type: rewardType_item}, 2: {Id: 102, Type: rewardType_exp}}
+	if err := valueRangeChecker.LoadOneCfgWithKey("rewardCfg", rewards, "Id"); err != nil {
+		t.Fatalf("load rewardCfg failed: %v", err)
+	}
+	if row, ok := valueRangeChecker.LookupRow("rewardCfg", 102); !ok || row.(*rewardCfg).Type != rewardType_exp {
+		t.Errorf("lookup reward row: %v, %v", row, ok)
+	}
+}
+
+func TestPrimaryKeyIllegal(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	if _, err := valueRangeChecker.LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(dupHeroCSV), heroCfg{}, CSVOptions{ListSep: "|", KVSep: "="}); err != nil {
+		t.Fatalf("load hero.csv failed: %v", err)
+	}
+
+	// 主键重复时列出每一个重复的行和第一次出现的行
+	err := valueRangeChecker.SetPrimaryKey(heroCfgKey, "Id")
+	want := "heroCfg primary key Id duplicate:\nhero.csv:4:A: heroCfg[2].Id: duplicate Id=61401, same as heroCfg[0].Id (hero.csv:2:A)"
+	if err == nil || err.Error() != want {
+		t.Errorf("err: %v\nwant: %s", err, want)
+	}
+	if _, ok := valueRangeChecker.LookupRow(heroCfgKey, 61401); ok {
+		t.Errorf("duplicate primary key should not be indexed")
+	}
+
+	valueRangeChecker.LoadOneCfg("oneCfg", heroCfgList[0])
+	cases := []struct {
+		cfgKey string
+		field  string
+		want   string
+	}{
+		{heroCfgKey, "Level", "primary key heroCfg.Level at [0]: row no field: Level"},
+		{heroCfgKey, "Open", "primary key heroCfg.Open at [0]: field: Open type no support, type: bool"},
+		{"oneCfg", "Id", "primary key ori data type no list or map, key: oneCfg, type: struct"},
+		{"noCfg", "Id", "primary key no load ori data key: noCfg"},
+	}
+	for _, c := range cases {
+		err := valueRangeChecker.SetPrimaryKey(c.cfgKey, c.field)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("%s.%s err: %v, want: %s", c.cfgKey, c.field, err, c.want)
+		}
+	}
+
+	// 规则文件中声明的主键，错误带上规则文件中的位置
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {"heroCfg": {"key": "Id", "list": "int"}}}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "rules.json:1:32: heroCfg primary key Id duplicate:\nhero.csv:4:A") {
+		t.Errorf("rule file primary key err: %v", err)
+	}
+	for _, c := range []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"key": 1, "list": "int"}}}`, `rules.json:1:26: table key must be a field name`},
+		{`{"tables": {"a": {"key": "Id", "struct": {"Id": "int"}}}}`, `rules.json:1:26: table key can only be used with list, map, is: struct`},
+	} {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
+
+type petId uint32
+
+type petCfg struct {
+	Id     petId
+	Master petId
+}
+
+// ref 主键走索引、ref 别的字段遍历整张表，能用的表是一样的：行可以是指针，字段可以是自定义的类型
+func TestPrimaryKeyRefConsistent(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	if err := valueRangeChecker.LoadOneCfgWithKey("petCfg", []*petCfg{{Id: 1}, {Id: 2, Master: 1}}, "Id"); err != nil {
+		t.Fatalf("load petCfg failed: %v", err)
+	}
+	valueRangeChecker.LoadOneCfg("petList", []*petCfg{{Id: 1}, {Id: 2, Master: 1}})
+	cases := []struct {
+		ref        string
+		pass, fail uint32
+	}{
+		{"petCfg.Id", 2, 3},
+		{"petCfg.Master", 1, 2},
+		{"petList.Id", 2, 3},
+	}
+	for _, c := range cases {
+		checker := valueRangeChecker.RefValueRangerChecker(c.ref)
+		if !checker.Check(c.pass) || checker.Check(c.fail) {
+			t.Errorf("ref %s check wrong", c.ref)
+		}
+	}
+
+	valueRangeChecker.LoadOneCfg("nilPet", []*petCfg{{Id: 1}, nil})
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "nilPet.Id[1]: row is nil") {
+			t.Errorf("recover: %v", r)
+		}
+	}()
+	valueRangeChecker.RefValueRangerChecker("nilPet.Id")
+}
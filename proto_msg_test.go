@@ -0,0 +1,92 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// 模拟 protobuf 生成的消息：到处是指针，oneof 是 interface，公共字段通过嵌入结构体
+type msgHead struct {
+	Seq uint32 `vr:"int=[1,-]"`
+}
+
+type isLoginReq_Auth interface {
+	isLoginReq_Auth()
+}
+
+type loginReq_Token struct {
+	Token string
+}
+
+type loginReq_Account struct {
+	Account string
+	Pwd     string
+}
+
+func (*loginReq_Token) isLoginReq_Auth()   {}
+func (*loginReq_Account) isLoginReq_Auth() {}
+
+type loginReq struct {
+	*msgHead
+	HeroId **uint64 `vr:"ref=heroCfg.Id"`
+	Auth   isLoginReq_Auth
+}
+
+// checker 中也嵌入，和值中被提升的字段对应
+type msgHeadChecker struct {
+	Seq ValueRangerChecker
+}
+
+type loginReqChecker struct {
+	msgHeadChecker
+	HeroId ValueRangerChecker
+	Auth   ValueRangerChecker
+}
+
+func TestProtoMsgChecker(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+	checker := valueRangeChecker.StructValueRangerChecker(loginReqChecker{
+		msgHeadChecker: msgHeadChecker{Seq: valueRangeChecker.IntValueRangerChecker("[1,-]")},
+		HeroId:         valueRangeChecker.RequiredValueRangerChecker(valueRangeChecker.RefValueRangerChecker(heroCfgKey + ".Id")), // 解开多层指针
+		Auth: valueRangeChecker.StructValueRangerChecker(struct{ Token ValueRangerChecker }{
+			Token: valueRangeChecker.StringValueRangerChecker(""),
+		}),
+	})
+	valueRangeChecker.RegChecker("loginReq", checker)
+	valueRangeChecker.RegChecker("compiledLoginReq", valueRangeChecker.CompileChecker(checker, &loginReq{}))
+	valueRangeChecker.RegChecker("tagLoginReq", valueRangeChecker.TagValueRangerChecker(&loginReq{}))
+
+	heroId, badHeroId := uint64(61401), uint64(9)
+	pHeroId, pBadHeroId := &heroId, &badHeroId
+	good := &loginReq{msgHead: &msgHead{Seq: 1}, HeroId: &pHeroId, Auth: &loginReq_Token{Token: "t"}}
+	bad := &loginReq{HeroId: &pBadHeroId, Auth: &loginReq_Account{Account: "a"}}
+	ptrPtr := &good
+
+	for _, key := range []string{"loginReq", "compiledLoginReq", "tagLoginReq"} {
+		if violations := valueRangeChecker.CheckWithReport(key, good); len(violations) != 0 {
+			t.Errorf("%s good violations: %v", key, violations)
+		}
+		if violations := valueRangeChecker.CheckWithReport(key, ptrPtr); len(violations) != 0 {
+			t.Errorf("%s **loginReq violations: %v", key, violations)
+		}
+	}
+
+	// 嵌入的指针是 nil、多层指针指向的值不对、oneof 中是另一种类型
+	wants := map[string][]string{
+		"loginReq":         {"loginReq.Seq: value field: Seq is in nil embedded ptr", "loginReq.HeroId: value 9", "loginReq.Auth.Token: value has no field: Token"},
+		"compiledLoginReq": {"compiledLoginReq.Seq: value field: Seq is in nil embedded ptr", "compiledLoginReq.HeroId: value 9", "compiledLoginReq.Auth.Token: value has no field: Token"},
+		"tagLoginReq":      {"tagLoginReq.Seq: value field: Seq is in nil embedded ptr", "tagLoginReq.HeroId: value 9"}, // Auth 没有规则，不检测
+	}
+	for key, want := range wants {
+		violations := valueRangeChecker.CheckWithReport(key, bad)
+		if len(violations) != len(want) {
+			t.Errorf("%s violations: %v", key, violations)
+			continue
+		}
+		for i := range want {
+			if !strings.Contains(violations[i].String(), want[i]) {
+				t.Errorf("%s violation %d: %s, want: %s", key, i, violations[i].String(), want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package valuerange
+
+import (
+	"fmt"
+	"os"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+	jsonnode "github.com/chenjinjie/value-range/internal/json-node"
+	ruleschema "github.com/chenjinjie/value-range/internal/rule-schema"
+)
+
+// 从规则文件加载枚举和各张表的检测规则，规则文件的格式见 internal/rule-schema
+// 规则文件中引用的配置表（ref）需要先通过 LoadOneCfg 加载好
+func (vr *ValueRange) LoadRuleFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return vr.LoadRules(path, data)
+}
+
+// 同 LoadRuleFile，file 只用在错误信息中
+// 出错时返回带位置的错误，如 rules.json:12:20: unknown rule "inte"
+func (vr *ValueRange) LoadRules(file string, data []byte) error {
+	schema, err := ruleschema.Parse(file, data)
+	if err != nil {
+		return err
+	}
+
+	// 先检测、创建好所有的东西，最后再一起注册，出错的时候不会留下注册了一半的枚举、主键、checker
+	enums := make(map[string]map[uint64]struct{}, len(schema.Enums))
+	for _, enum := range schema.Enums {
+		if vr.enumStore.EnumRuleExit(enum.Key) {
+			return jsonnode.Errorf(enum.Pos, "enum %q load failed, duplicate key", enum.Key)
+		}
+		enumData := make(map[uint64]struct{}, len(enum.Values))
+		for _, value := range enum.Values {
+			enumData[value] = struct{}{}
+		}
+		enums[enum.Key] = enumData
+	}
+
+	var primaryKeys []ruleschema.Table
+	for _, table := range schema.Tables {
+		if table.PrimaryKey == "" {
+			continue
+		}
+		if field, ok := vr.refStore.PrimaryKey(table.Key); ok && field == table.PrimaryKey {
+			continue // 加载数据的时候已经声明过了
+		}
+		if err := vr.primaryKeyError(table.Key, table.PrimaryKey, vr.refStore.CheckPrimaryKey(table.Key, table.PrimaryKey)); err != nil {
+			return jsonnode.Errorf(table.PrimaryKeyPos, "%s", err.Error())
+		}
+		primaryKeys = append(primaryKeys, table)
+	}
+
+	// 规则中用到的枚举在创建 checker 的时候就要有，用一份加上了规则文件中的枚举的副本来创建
+	// 副本和 vr 中枚举的数据是共用的，checker 引用副本检测的结果是一样的
+	staged := *vr
+	staged.enumStore = vr.enumStore.Clone()
+	for key, enumData := range enums {
+		staged.enumStore.LoadOneEnum(key, enumData)
+	}
+	checkers := make([]ValueRangerChecker, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		if _, ok := vr.checkerStore[table.Key]; ok {
+			return jsonnode.Errorf(table.Pos, "table %q checker duplicate", table.Key)
+		}
+		checker, err := staged.schemaChecker(table.Rule)
+		if err != nil {
+			return err
+		}
+		checkers = append(checkers, checker)
+	}
+
+	for key, enumData := range enums {
+		vr.LoadOneEnumCfg(key, enumData)
+	}
+	for _, table := range primaryKeys {
+		if err := vr.SetPrimaryKey(table.Key, table.PrimaryKey); err != nil { // 上面检测过了，不会出错
+			panic(err)
+		}
+	}
+	for i, table := range schema.Tables {
+		vr.RegChecker(table.Key, checkers[i])
+	}
+	return nil
+}
+
+// 根据规则文件中的规则创建 checker
+func (vr *ValueRange) schemaChecker(rule *ruleschema.Rule) (ValueRangerChecker, error) {
+	switch rule.Kind {
+	case "list":
+		elemChecker, err := vr.schemaChecker(rule.Elem)
+		if err != nil {
+			return nil, err
+		}
+		lr := basetyperange.ListValueRangerChecker(elemChecker)
+		for _, fields := range rule.Unique {
+			lr.AddUnique(fields...)
+		}
+		for _, o := range rule.Orders {
+			order, err := basetyperange.ParseOrder(o.Order)
+			if err != nil {
+				return nil, jsonnode.Errorf(o.Pos, "%s", err.Error())
+			}
+			lr.AddOrder(order, o.Field)
+		}
+		aggregates, err := schemaAggregates(rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := vr.schemaSequences(vr.WithAggregates(lr, aggregates...), rule); err != nil {
+			return nil, err
+		}
+		return vr.schemaLen(lr, rule)
+	case "map":
+		keyChecker, err := vr.schemaChecker(rule.Key)
+		if err != nil {
+			return nil, err
+		}
+		elemChecker, err := vr.schemaChecker(rule.Elem)
+		if err != nil {
+			return nil, err
+		}
+		mr := basetyperange.MapValueRangerChecker(keyChecker, elemChecker)
+		for _, fields := range rule.Unique {
+			mr.AddUnique(fields...)
+		}
+		aggregates, err := schemaAggregates(rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := vr.schemaSequences(vr.WithAggregates(mr, aggregates...), rule); err != nil {
+			return nil, err
+		}
+		if err := vr.schemaMapKeys(mr, rule); err != nil {
+			return nil, err
+		}
+		return vr.schemaLen(mr, rule)
+	case "struct":
+		sr := basetyperange.EmptyStructValueRangerChecker().WithStrict(vr.strictMode)
+		for _, field := range rule.Fields {
+			fieldChecker, err := vr.schemaChecker(field.Rule)
+			if err != nil {
+				return nil, err
+			}
+			sr.AddField(field.Name, fieldChecker)
+		}
+		if rule.Switch != nil {
+			sw, err := vr.schemaSwitch(rule.Switch)
+			if err != nil {
+				return nil, err
+			}
+			sr.AddSwitch(sw)
+		}
+		for _, check := range rule.Checks {
+			sr.AddConstraint(basetyperange.ExprConstraint(check))
+		}
+		return sr, nil
+	default:
+		checker, err := vr.ruleChecker(rule.Kind, rule.Arg)
+		if err != nil {
+			return nil, jsonnode.Errorf(rule.Pos, "%s", err.Error())
+		}
+		return checker, nil
+	}
+}
+
+func (vr *ValueRange) schemaLen(checker ValueRangerChecker, rule *ruleschema.Rule) (ValueRangerChecker, error) {
+	if rule.Len == "" {
+		return checker, nil
+	}
+	checker, err := vr.withLenErr(checker, rule.Len)
+	if err != nil {
+		return nil, jsonnode.Errorf(rule.LenPos, "%s", err.Error())
+	}
+	return checker, nil
+}
+
+func schemaAggregates(rule *ruleschema.Rule) ([]*basetyperange.Aggregate, error) {
+	aggregates := make([]*basetyperange.Aggregate, 0, len(rule.Aggregates))
+	for _, a := range rule.Aggregates {
+		aggregate, err := schemaAggregate(a)
+		if err != nil {
+			return nil, jsonnode.Errorf(a.Pos, "%s", err.Error())
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+	return aggregates, nil
+}
+
+// 区间不合法时创建会 panic，这里转为错误
+func schemaAggregate(rule ruleschema.AggregateRule) (result *basetyperange.Aggregate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("%s %v", rule.Kind, r)
+		}
+	}()
+	switch rule.Kind {
+	case "sum":
+		return basetyperange.SumAggregate(rule.Arg, rule.Range), nil
+	case "min":
+		return basetyperange.MinAggregate(rule.Arg, rule.Range), nil
+	case "max":
+		return basetyperange.MaxAggregate(rule.Arg, rule.Range), nil
+	default:
+		return basetyperange.CountAggregate(rule.Arg, rule.Range), nil
+	}
+}
+
+// 区间不合法时创建会 panic，这里转为错误
+func (vr *ValueRange) schemaSequences(checker ValueRangerChecker, rule *ruleschema.Rule) (err error) {
+	var pos jsonnode.Pos
+	defer func() {
+		if r := recover(); r != nil {
+			err = jsonnode.Errorf(pos, "sequence %v", r)
+		}
+	}()
+	for _, s := range rule.Sequences {
+		pos = s.Pos
+		vr.WithSequence(checker, s.Field, s.Range)
+	}
+	return nil
+}
+
+func (vr *ValueRange) schemaMapKeys(mr *basetyperange.MapRange, rule *ruleschema.Rule) (err error) {
+	var pos jsonnode.Pos
+	defer func() {
+		if r := recover(); r != nil {
+			err = jsonnode.Errorf(pos, "%v", r)
+		}
+	}()
+	if len(rule.RequiredKeys) > 0 {
+		keys := make([]any, 0, len(rule.RequiredKeys))
+		for _, key := range rule.RequiredKeys {
+			keys = append(keys, key)
+		}
+		pos = rule.RequiredKeysPos
+		vr.WithRequiredKeys(mr, keys...)
+	}
+	if rule.EnumKeys != "" {
+		pos = rule.EnumKeysPos
+		vr.WithEnumKeys(mr, rule.EnumKeys)
+	}
+	return nil
+}
+
+func (vr *ValueRange) schemaSwitch(rule *ruleschema.SwitchRule) (*basetyperange.SwitchRange, error) {
+	sw := basetyperange.SwitchValueRangerChecker(rule.Field)
+	for _, c := range rule.Cases {
+		caseChecker, err := vr.schemaChecker(c.Rule)
+		if err != nil {
+			return nil, err
+		}
+		sw.AddCase(c.Value, caseChecker)
+	}
+	if rule.Default != nil {
+		defaultChecker, err := vr.schemaChecker(rule.Default)
+		if err != nil {
+			return nil, err
+		}
+		sw.WithDefault(defaultChecker)
+	}
+	return sw, nil
+}
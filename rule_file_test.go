@@ -0,0 +1,81 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg(heroCfgKey, heroCfgList)
+	valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList)
+
+	if err := valueRangeChecker.LoadRuleFile("testdata/rules.json"); err != nil {
+		t.Fatalf("load rule file failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	if !report.Pass() || len(report.Tables) != 2 || len(report.NoChecker) != 0 {
+		t.Fatalf("check all failed:\n%s", report.String())
+	}
+	if valueRangeChecker.Check(heroCfgKey, []heroCfg{{Quality: 7}}) {
+		t.Errorf("rule file checker should fail on bad quality")
+	}
+}
+
+func TestLoadRulesMalformed(t *testing.T) {
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": "inte"}}`, `rules.json:1:18: unknown rule "inte"`},
+		{"{\"tables\": {\n\t\"a\": {\"list\": \"int=[5,1]\"}\n}}", `rules.json:2:16: IntRange max value less than min value`},
+		{`{"tables": {"a": {"list": "int", "map": "int"}}}`, `rules.json:1:34: rule object must have exactly one of list, map, struct`},
+		{`{"tables": {"a": {"map": {"value": "int"}}}}`, `rules.json:1:26: map rule need both key and value`},
+		{`{"tables": {"a": "enum=noEnum"}}`, `rules.json:1:18: EnumValueRangerChecker enumKey not exit: noEnum`},
+		{`{"enums": {"e": [1, -2]}}`, `rules.json:1:21: want a non-negative integer`},
+		{`{"tables": {"a": "int",}}`, `rules.json:1:24: invalid character`},
+		{`{"table": {}}`, `rules.json:1:2: unknown key "table"`},
+		{`{"tables": {"a": "int", "a": "int"}}`, `rules.json:1:25: duplicate key "a"`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
+
+// 加载失败时不留下注册了一半的枚举、主键、checker，改好规则文件再加载一次就行
+func TestLoadRulesAtomic(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg(heroCfgKey, heroCfgList)
+	valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList)
+
+	rules := `{"enums": {"heroCfgQuality": [1, 2, 3, 4, 5]}, "tables": {
+		"heroCfg":     {"key": "Id", "list": {"struct": {"Quality": "enum=heroCfgQuality"}}},
+		"heroSkinCfg": {"list": {"struct": {"Id": "%s"}}}
+	}}`
+	err := valueRangeChecker.LoadRules("rules.json", []byte(strings.Replace(rules, "%s", "int=[5,1]", 1)))
+	if err == nil || !strings.Contains(err.Error(), "IntRange max value less than min value") {
+		t.Fatalf("load bad rules err: %v", err)
+	}
+	if valueRangeChecker.enumStore.EnumRuleExit(enumHeroCfgQualityKey) {
+		t.Errorf("enum should not be loaded")
+	}
+	if _, ok := valueRangeChecker.refStore.PrimaryKey(heroCfgKey); ok {
+		t.Errorf("primary key should not be set")
+	}
+	if _, ok := valueRangeChecker.checkerStore[heroCfgKey]; ok {
+		t.Errorf("checker should not be registered")
+	}
+
+	if err := valueRangeChecker.LoadRules("rules.json", []byte(strings.Replace(rules, "%s", "int", 1))); err != nil {
+		t.Fatalf("load fixed rules failed: %v", err)
+	}
+	if report := valueRangeChecker.CheckAll(); !report.Pass() || len(report.Tables) != 2 {
+		t.Errorf("check all failed:\n%s", report.String())
+	}
+	if _, ok := valueRangeChecker.LookupRow(heroCfgKey, heroCfgList[0].Id); !ok {
+		t.Errorf("primary key should be set")
+	}
+}
@@ -0,0 +1,110 @@
+package valuerange
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type chapterCfg struct {
+	Id   uint32
+	Name string
+}
+
+func TestSequence(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithSequence(valueRangeChecker.MapValueRangerChecker(
+			valueRangeChecker.IntValueRangerChecker(""),
+			valueRangeChecker.StructValueRangerChecker(struct{ Id ValueRangerChecker }{Id: valueRangeChecker.IntValueRangerChecker("")}),
+		), "", "[1,10]")
+	}
+
+	// map 是 key，缺了的和区间外的值连续的合并起来
+	chapters := map[uint32]*chapterCfg{1: {Id: 1}, 2: {Id: 2}, 5: {Id: 5}, 6: {Id: 6}, 30: {Id: 30}, 31: {Id: 31}}
+	checkBothPaths(t, valueRangeChecker, "chapter", newChecker, map[uint32]*chapterCfg{}, chapters, ": sequence [1,10] not contiguous, missing: 3-4, 7-10, extra: 30-31")
+
+	// 元素不通过时也接着检测连续，两个都报出来
+	newListChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithSequence(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("[1,10]")), "", "[1,3]")
+	}
+	checkBothPaths(t, valueRangeChecker, "chapterList", newListChecker, []int{}, []int{1, 20},
+		"[1]: value 20 (int) not match int[1,10]", ": sequence [1,3] not contiguous, missing: 2-3, extra: 20")
+
+	cases := []struct {
+		field    string
+		rangeStr string
+		value    any
+		want     string
+	}{
+		{"", "(0,-)", []int{3, 1, 0, 1}, "sequence (0,-) not contiguous, missing: 2, extra: 0"},
+		{"", "[1,-]", []int{}, ""}, // 没有上限时空的不检测，不能为空用 WithLen
+		{"", "[1,3]", []int{}, "sequence [1,3] not contiguous, missing: 1-3"},
+		{"Id", "[1,-]", []chapterCfg{{Id: 2}, {Id: 1}, {Id: 3}}, ""},
+		{"Name", "[1,-]", []chapterCfg{{Id: 1, Name: "a"}}, "sequence(Name) error: at [0]: value a is not an int"},
+	}
+	for i, c := range cases {
+		checker := valueRangeChecker.WithSequence(valueRangeChecker.ListValueRangerChecker(anyValueChecker{}), c.field, c.rangeStr)
+		key := fmt.Sprintf("seq%d", i)
+		valueRangeChecker.RegChecker(key, checker)
+		violations := valueRangeChecker.CheckWithReport(key, c.value)
+		got := ""
+		if len(violations) > 0 {
+			got = violations[0].Msg
+		}
+		if len(violations) > 1 || got != c.want {
+			t.Errorf("case %d violations: %v, want: %s", i, violations, c.want)
+		}
+	}
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.WithSequence(valueRangeChecker.IntValueRangerChecker(""), "", "[1,-]") },
+		func() {
+			valueRangeChecker.WithSequence(valueRangeChecker.ListValueRangerChecker(valueRangeChecker.IntValueRangerChecker("")), "", "")
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestSequenceRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	_, err := valueRangeChecker.LoadOneCSVCfg("levelCfg", "level.csv", strings.NewReader(levelCSV), levelCfg{}, CSVOptions{ListSep: "|"})
+	if err != nil {
+		t.Fatalf("load level.csv failed: %v", err)
+	}
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"levelCfg": {"list": {"struct": {"Level": "int"}}, "sequence": {"Level": "[1,6]"}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	// 记在整张表上，位置是文件
+	report := valueRangeChecker.CheckAll()
+	violations := report.Tables[0].Violations
+	if len(violations) != 1 || violations[0].String() != "level.csv: levelCfg: sequence(Level) [1,6] not contiguous, missing: 4, 6" {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"list": "int", "sequence": "[1,"}}}`, `rules.json:1:46: sequence IntRange pattern not illegal: [1,`},
+		{`{"tables": {"a": {"list": "int", "sequence": {"Level": 1}}}}`, `rules.json:1:47: sequence must be field name => range string`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "sequence": "[1,-]"}}}`, `rules.json:1:44: sequence can only be used with list, map, is: struct`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// heroSkinCfg 加了 Desc 列，checker 却只有 Id
+type heroSkinIdChecker struct {
+	Id ValueRangerChecker
+}
+
+func TestStrictMode(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg(heroSkinCfgKey, heroSkinCfgList)
+
+	checker := valueRangeChecker.StructValueRangerChecker(heroSkinIdChecker{Id: valueRangeChecker.IntValueRangerChecker("")})
+	if !checker.Check(heroSkinCfgList[0]) {
+		t.Errorf("non strict checker should ignore Desc")
+	}
+	strictChecker := valueRangeChecker.WithStrictMode(valueRangeChecker.StructValueRangerChecker(heroSkinIdChecker{Id: valueRangeChecker.IntValueRangerChecker("")}), StrictFail)
+	compiledChecker := valueRangeChecker.CompileChecker(strictChecker, heroSkinCfg{})
+	for _, c := range []ValueRangerChecker{strictChecker, compiledChecker} {
+		if c.Check(heroSkinCfgList[0]) || c.Check(&heroSkinCfgList[0]) {
+			t.Errorf("%s should fail on Desc", c.ToString())
+		}
+	}
+	valueRangeChecker.RegChecker("strictSkin", compiledChecker)
+	violations := valueRangeChecker.CheckWithReport("strictSkin", heroSkinCfgList[0])
+	if len(violations) != 1 || violations[0].String() != "strictSkin.Desc: value field: Desc has no rule (strict)" {
+		t.Errorf("violations: %v", violations)
+	}
+
+	// 全局默认的严格模式，之后创建的 checker 都是 StrictWarn，只提醒不影响通过
+	valueRangeChecker.SetStrictMode(StrictWarn)
+	valueRangeChecker.RegChecker(heroSkinCfgKey, valueRangeChecker.ListValueRangerChecker(
+		valueRangeChecker.StructValueRangerChecker(heroSkinIdChecker{Id: valueRangeChecker.IntValueRangerChecker("")}),
+	))
+	report := valueRangeChecker.CheckAll()
+	if !report.Pass() || len(report.Tables) != 1 || len(report.Tables[0].Warnings) != len(heroSkinCfgList) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	if !strings.Contains(report.String(), "heroSkinCfg: PASS\n  warning: heroSkinCfg[0].Desc: value field: Desc has no rule\n") {
+		t.Errorf("check all report:\n%s", report.String())
+	}
+}
+
+func TestStrictModeNested(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+	valueRangeChecker.SetStrictMode(StrictFail)
+
+	// 嵌入结构体提升上来的字段都有规则
+	loginChecker := valueRangeChecker.StructValueRangerChecker(loginReqChecker{
+		msgHeadChecker: msgHeadChecker{Seq: valueRangeChecker.IntValueRangerChecker("")},
+		HeroId:         valueRangeChecker.OptionalValueRangerChecker(valueRangeChecker.IntValueRangerChecker("")),
+		Auth:           valueRangeChecker.OptionalValueRangerChecker(valueRangeChecker.TagValueRangerChecker(loginReq_Token{})),
+	})
+	if !loginChecker.Check(&loginReq{msgHead: &msgHead{Seq: 1}, Auth: &loginReq_Token{Token: "t"}}) {
+		t.Errorf("strict login checker failed")
+	}
+
+	// tag 生成的 checker 也用全局的严格模式，map[string]any 中多的 key 也算没有规则的字段
+	tagChecker := valueRangeChecker.TagValueRangerChecker(heroTagCfg{})
+	if !tagChecker.Check(heroTagCfg{}) || !tagChecker.Check(map[string]any{"Free": true}) {
+		t.Errorf("strict tag checker failed")
+	}
+	if tagChecker.Check(map[string]any{"Free": true, "Price": 6}) {
+		t.Errorf("strict tag checker should fail on extra key")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("WithStrictMode on non struct checker should panic")
+		}
+	}()
+	valueRangeChecker.WithStrictMode(valueRangeChecker.IntValueRangerChecker(""), StrictFail)
+}
@@ -0,0 +1,162 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	rewardType_item     = "item"
+	rewardType_currency = "currency"
+	rewardType_exp      = "exp"
+)
+
+type rewardCfg struct {
+	Id    uint64
+	Type  string
+	Value uint64
+}
+
+type itemCfg struct {
+	Id uint64
+}
+
+type rewardCfgChecker struct {
+	Id   ValueRangerChecker
+	Type ValueRangerChecker
+}
+
+func newRewardValueRange(t *testing.T) *ValueRange {
+	valueRangeChecker := ValueRangeChecker()
+	if !valueRangeChecker.LoadOneCfg("itemCfg", []itemCfg{{Id: 1001}, {Id: 1002}}) {
+		t.Fatalf("load itemCfg data failed")
+	}
+	valueRangeChecker.LoadOneEnumCfg("currency", map[uint64]struct{}{1: {}, 2: {}})
+	return valueRangeChecker
+}
+
+func TestSwitch(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	valueRangeChecker.SetStrictMode(StrictFail) // Value 只在分支中检测，也不算没有规则
+	newChecker := func() ValueRangerChecker {
+		valueChecker := func(checker ValueRangerChecker) ValueRangerChecker {
+			return valueRangeChecker.StructValueRangerChecker(struct{ Value ValueRangerChecker }{Value: checker})
+		}
+		return valueRangeChecker.WithSwitch(valueRangeChecker.StructValueRangerChecker(rewardCfgChecker{
+			Id:   valueRangeChecker.IntValueRangerChecker(""),
+			Type: valueRangeChecker.StringValueRangerChecker(""),
+		}), valueRangeChecker.SwitchValueRangerChecker("Type", SwitchCases{
+			rewardType_item:     valueChecker(valueRangeChecker.RefValueRangerChecker("itemCfg.Id")),
+			rewardType_currency: valueChecker(valueRangeChecker.EnumValueRangerChecker("currency")),
+			rewardType_exp:      valueChecker(valueRangeChecker.IntValueRangerChecker("[1,10000]")),
+		}, nil))
+	}
+
+	goods := []rewardCfg{
+		{Id: 1, Type: rewardType_item, Value: 1001},
+		{Id: 2, Type: rewardType_currency, Value: 2},
+		{Id: 3, Type: rewardType_exp, Value: 500},
+	}
+	for i := range goods {
+		checkBothPaths(t, valueRangeChecker, "reward", newChecker, rewardCfg{}, &goods[i])
+	}
+	bads := []struct {
+		row  rewardCfg
+		want string
+	}{
+		{rewardCfg{Id: 4, Type: rewardType_item, Value: 2}, ".Value: value 2 (uint64) not match ref(itemCfg.Id)"},
+		{rewardCfg{Id: 5, Type: rewardType_currency, Value: 1001}, ".Value: value 1001 (uint64) not match enum(currency)"},
+		{rewardCfg{Id: 6, Type: rewardType_exp, Value: 0}, ".Value: value 0 (uint64) not match int[1,10000]"},
+		{rewardCfg{Id: 7, Type: "gem", Value: 1}, ".Type: switch Type value: gem has no case, want one of: currency, exp, item"},
+	}
+	for _, bad := range bads {
+		checkBothPaths(t, valueRangeChecker, "reward", nil, nil, bad.row, bad.want)
+	}
+
+	// 直接当字段的 checker 用，分支字段读不出来时记在分支字段上；有 default 时不在 case 中的值用 default
+	fieldChecker := valueRangeChecker.StructValueRangerChecker(struct{ Reward ValueRangerChecker }{
+		Reward: valueRangeChecker.SwitchValueRangerChecker("Type", SwitchCases{
+			"1": valueRangeChecker.StructValueRangerChecker(struct{ Value ValueRangerChecker }{Value: valueRangeChecker.RefValueRangerChecker("itemCfg.Id")}),
+		}, valueRangeChecker.StructValueRangerChecker(struct{ Value ValueRangerChecker }{Value: valueRangeChecker.IntValueRangerChecker("[1,-]")})),
+	})
+	valueRangeChecker.RegChecker("quest", fieldChecker)
+	if !fieldChecker.Check(map[string]any{"Reward": map[string]any{"Type": 1.0, "Value": uint64(1002)}}) ||
+		!fieldChecker.Check(struct{ Reward rewardCfg }{rewardCfg{Type: rewardType_exp, Value: 3}}) {
+		t.Errorf("switch field checker failed")
+	}
+	violations := valueRangeChecker.CheckWithReport("quest", map[string]any{"Reward": map[string]any{"Value": 1}})
+	if len(violations) != 1 || violations[0].String() != "quest.Reward.Type: switch Type error: value has no key: Type" {
+		t.Errorf("quest violations: %v", violations)
+	}
+
+	// 用作 case 的 struct checker 在别的地方还是严格模式
+	expChecker := valueRangeChecker.StructValueRangerChecker(struct{ Value ValueRangerChecker }{Value: valueRangeChecker.IntValueRangerChecker("[1,10000]")})
+	valueRangeChecker.SwitchValueRangerChecker("Type", SwitchCases{rewardType_exp: expChecker}, expChecker)
+	valueRangeChecker.RegChecker("exp", expChecker)
+	violations = valueRangeChecker.CheckWithReport("exp", rewardCfg{Type: rewardType_exp, Value: 3})
+	if len(violations) != 2 || violations[0].String() != "exp.Id: value field: Id has no rule (strict)" {
+		t.Errorf("exp violations: %v", violations)
+	}
+
+	for _, fn := range []func(){
+		func() { valueRangeChecker.SwitchValueRangerChecker("Type", SwitchCases{}, nil) },
+		func() {
+			valueRangeChecker.SwitchValueRangerChecker("Type", SwitchCases{1: fieldChecker, "1": fieldChecker}, nil)
+		},
+		func() { valueRangeChecker.WithSwitch(fieldChecker, fieldChecker) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("should panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestSwitchRuleFile(t *testing.T) {
+	valueRangeChecker := newRewardValueRange(t)
+	valueRangeChecker.LoadOneCfg("rewardCfg", []rewardCfg{
+		{Id: 1, Type: rewardType_item, Value: 1001},
+		{Id: 2, Type: rewardType_item, Value: 1},
+		{Id: 3, Type: rewardType_exp, Value: 0},
+	})
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"rewardCfg": {"list": {"struct": {"Id": "int", "Type": "string"}, "switch": {"field": "Type", "cases": {
+			"item":     {"struct": {"Value": "ref=itemCfg.Id"}},
+			"currency": {"struct": {"Value": "enum=currency"}}
+		}, "default": {"struct": {"Value": "int=[1,10000]"}}}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	report := valueRangeChecker.CheckAll()
+	wants := []string{"rewardCfg[1].Value: value 1", "rewardCfg[2].Value: value 0"}
+	violations := report.Tables[0].Violations
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if !strings.HasPrefix(violations[i].String(), want) {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"list": "int", "switch": {}}}}`, `rules.json:1:34: switch can only be used with struct`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "switch": {"field": "Type"}}}}`, `rules.json:1:54: switch need both field and cases`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "switch": {"field": "Type", "cases": {"1": "int", "1": "int"}}}}}`, `rules.json:1:94: duplicate key "1"`},
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "switch": {"field": "Type", "case": {}}}}}`, `rules.json:1:72: unknown switch key "case"`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
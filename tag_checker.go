@@ -0,0 +1,395 @@
+package valuerange
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
+)
+
+// 通过 struct tag 声明检测规则，不用再写一个一一对应的 checker 结构体
+//
+//	type heroCfg struct {
+//		Id      uint64            `vr:"int=[1,-]"`
+//		Quality int               `vr:"enum=heroCfgQuality"`
+//		Skins   []uint64          `vr:"list,ref=heroSkinCfg.Id"`
+//		Attrs   map[uint32]uint32 `vr:"map,key:enum=heroCfgAttr,int=(0,-)"`
+//		Slots   []uint64          `vr:"list=[3,3],ref=itemCfg.Id"` // 正好 3 个
+//		Tag     heroTagCfg        // 结构体字段递归使用 heroTagCfg 中的 tag
+//		Ignore  string            `vr:"-"` // 不检测
+//		Level   *int              `vr:"optional,int=[1,5]"` // 可以不填
+//	}
+//
+// tag 中用逗号分隔（区间中的逗号不算）：
+//  1. list / map  - 容器标记，可选，写了的话要和字段的类型一层层对上；list=<区间>、map=<区间> 同时限制长度
+//  2. key:<规则>  - map 的 key 的规则，不写的话按 key 的类型只检测类型；结构体、数组的 key 不能写，按它自己的类型和字段的 vr tag 生成
+//  3. <规则>      - 最里层元素的规则，不写的话按类型只检测类型
+//  4. optional / required - 字段可以不填 / 必须填，不填指的是 nil 指针、nil slice、nil map
+//
+// 没有写 optional / required 时，nil 指针是不通过的，nil slice、nil map 当作空的
+// 多层指针和一层一样；嵌入的结构体和 Go 中一样，字段提升上来检测；interface 字段没有写规则的不检测
+//
+// 规则：int、int=<区间>、string、bool、bool=true|false、enum=<枚举key>、ref=<表.字段>
+const ruleTagKey = "vr"
+
+// 根据 sample 类型上的 vr tag 生成 checker，sample 可以是 struct、struct 的指针、struct 的 slice/map 等
+// tag 不合法时 panic，和其他 checker 的创建一样，在注册的地方统一 recover
+func (vr *ValueRange) TagValueRangerChecker(sample any) ValueRangerChecker {
+	sampleType := reflect.TypeOf(sample)
+	if sampleType == nil {
+		panic("TagValueRangerChecker sample is nil")
+	}
+	tb := &tagBuilder{vr: vr, building: make(map[reflect.Type]struct{})}
+	checker, err := tb.build(sampleType, &tagRule{}, sampleType.String())
+	if err != nil {
+		panic(err.Error())
+	}
+	if checker == nil {
+		panic("TagValueRangerChecker sample type has no rule: " + sampleType.String())
+	}
+	return checker
+}
+
+// 一个字段上的 tag 解析后的结果
+type tagRule struct {
+	containers []string // list / map 标记，按出现的顺序
+	lens       []string // 和 containers 一一对应的长度区间，没有写时为空
+	presence   string   // optional / required，没有写时为空
+	keyRule    string   // map key 的规则
+	leafRule   string   // 最里层元素的规则
+
+	containerPos int  // 已经对上了几层容器标记
+	keyUsed      bool // key 规则已经用过了，只用在第一层 map 上
+}
+
+func parseTagRule(tag string) (*tagRule, error) {
+	rule := &tagRule{}
+	if strings.TrimSpace(tag) == "" {
+		return rule, nil
+	}
+	for _, item := range splitRuleItems(tag) {
+		switch {
+		case item == "":
+			return nil, fmt.Errorf("empty item in tag %q", tag)
+		case item == "optional" || item == "required":
+			if rule.presence != "" {
+				return nil, fmt.Errorf("duplicate %q and %q in tag %q", rule.presence, item, tag)
+			}
+			rule.presence = item
+		case item == "list" || item == "map" || strings.HasPrefix(item, "list=") || strings.HasPrefix(item, "map="):
+			if rule.leafRule != "" {
+				return nil, fmt.Errorf("container %q must be before rule %q in tag %q", item, rule.leafRule, tag)
+			}
+			container, lenStr, hasLen := strings.Cut(item, "=")
+			if hasLen && lenStr == "" {
+				return nil, fmt.Errorf("container %q need a len range, like %s=[1,-] in tag %q", item, container, tag)
+			}
+			rule.containers = append(rule.containers, container)
+			rule.lens = append(rule.lens, lenStr)
+		case strings.HasPrefix(item, "key:"):
+			if rule.keyRule != "" {
+				return nil, fmt.Errorf("duplicate key rule in tag %q", tag)
+			}
+			rule.keyRule = strings.TrimPrefix(item, "key:")
+		default:
+			if rule.leafRule != "" {
+				return nil, fmt.Errorf("duplicate rule %q and %q in tag %q", rule.leafRule, item, tag)
+			}
+			rule.leafRule = item
+		}
+	}
+	return rule, nil
+}
+
+// 按逗号分隔，区间 [0,10]、(0,-) 中的逗号不算
+func splitRuleItems(str string) []string {
+	var items []string
+	depth, start := 0, 0
+	for i, c := range str {
+		switch c {
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(str[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(str[start:]))
+}
+
+type tagBuilder struct {
+	vr       *ValueRange
+	building map[reflect.Type]struct{} // 正在生成的结构体类型，防止递归类型死循环
+}
+
+func (tb *tagBuilder) build(valueType reflect.Type, rule *tagRule, path string) (ValueRangerChecker, error) {
+	switch valueType.Kind() {
+	case reflect.Slice, reflect.Array:
+		lenStr, err := rule.takeContainer("list", valueType)
+		if err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+		elemChecker, err := tb.build(valueType.Elem(), rule, path+"[]")
+		if err != nil || elemChecker == nil { // 元素是没有规则的 interface，整个列表都不检测
+			return nil, err
+		}
+		return tb.withLen(tb.vr.ListValueRangerChecker(elemChecker), lenStr, path)
+
+	case reflect.Map:
+		lenStr, err := rule.takeContainer("map", valueType)
+		if err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+		keyRule := ""
+		if !rule.keyUsed {
+			keyRule, rule.keyUsed = rule.keyRule, true
+		}
+		var keyChecker ValueRangerChecker
+		switch valueType.Key().Kind() {
+		case reflect.Struct, reflect.Array: // 结构体、数组的 key 按它自己的类型和字段的 vr tag 生成
+			if keyRule != "" {
+				return nil, fmt.Errorf("vr tag %s: key rule %q no support key type: %s", path, keyRule, valueType.Key().String())
+			}
+			keyChecker, err = tb.build(valueType.Key(), &tagRule{}, path+"[key]")
+		default:
+			keyChecker, err = tb.leaf(valueType.Key(), keyRule, path+"[key]")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if keyChecker == nil {
+			return nil, fmt.Errorf("vr tag %s: type no support: %s", path+"[key]", valueType.Key().String())
+		}
+		elemChecker, err := tb.build(valueType.Elem(), rule, path+"[]")
+		if err != nil || elemChecker == nil { // 值是没有规则的 interface，整个 map 都不检测
+			return nil, err
+		}
+		return tb.withLen(tb.vr.MapValueRangerChecker(keyChecker, elemChecker), lenStr, path)
+
+	case reflect.Ptr: // 多层指针和一层一样，检测的时候会解开所有层
+		elemType := valueType.Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		elemChecker, err := tb.build(elemType, rule, path)
+		if err != nil || elemChecker == nil {
+			return elemChecker, err
+		}
+		if elemType.Kind() == reflect.Struct { // StructRange 自己会解开指针
+			return elemChecker, nil
+		}
+		return tb.vr.RequiredValueRangerChecker(elemChecker), nil // 解开引用，nil 指针不通过
+
+	case reflect.Interface: // 具体类型要到检测的时候才知道，没有写规则的不检测
+		if err := rule.checkAllUsed(valueType); err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+		if rule.leafRule == "" {
+			return nil, nil
+		}
+		return tb.leaf(valueType, rule.leafRule, path)
+
+	case reflect.Struct:
+		if err := rule.checkAllUsed(valueType); err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+		if rule.leafRule != "" {
+			return nil, fmt.Errorf("vr tag %s: rule %q can not be used on struct type %s", path, rule.leafRule, valueType.String())
+		}
+		return tb.structChecker(valueType, path)
+
+	default:
+		if err := rule.checkAllUsed(valueType); err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+		return tb.leaf(valueType, rule.leafRule, path)
+	}
+}
+
+func (tb *tagBuilder) structChecker(structType reflect.Type, path string) (ValueRangerChecker, error) {
+	if _, ok := tb.building[structType]; ok {
+		return nil, fmt.Errorf("vr tag %s: recursive struct type no support: %s", path, structType.String())
+	}
+	tb.building[structType] = struct{}{}
+	defer delete(tb.building, structType)
+
+	// 嵌入的结构体和 Go 中一样，字段提升上来一个个检测，VisibleFields 中嵌入的结构体后面紧跟着它提升上来的字段
+	sr := basetyperange.EmptyStructValueRangerChecker().WithStrict(tb.vr.strictMode)
+	var skipped [][]int // vr:"-" 的嵌入结构体，它提升上来的字段也不检测
+	for _, field := range reflect.VisibleFields(structType) {
+		if inSkipped(field.Index, skipped) {
+			continue
+		}
+		tag, _ := field.Tag.Lookup(ruleTagKey)
+		if tag == "-" {
+			skipped = append(skipped, field.Index)
+			continue
+		}
+		fieldPath := path + "." + field.Name
+		if field.Anonymous && isStructOrPtrStruct(field.Type) {
+			if strings.TrimSpace(tag) != "" {
+				return nil, fmt.Errorf("vr tag %s: embedded struct can only use tag \"-\", write rules on its fields", fieldPath)
+			}
+			continue
+		}
+		if !field.IsExported() { // 未导出的字段拿不到值，不检测
+			continue
+		}
+		rule, err := parseTagRule(tag)
+		if err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", fieldPath, err.Error())
+		}
+		fieldChecker, err := tb.build(field.Type, rule, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		if fieldChecker == nil { // 没有规则的 interface 字段
+			continue
+		}
+		switch rule.presence {
+		case "optional":
+			fieldChecker = tb.vr.OptionalValueRangerChecker(fieldChecker)
+		case "required":
+			fieldChecker = tb.vr.RequiredValueRangerChecker(fieldChecker)
+		}
+		sr.AddField(field.Name, fieldChecker)
+	}
+	return sr, nil
+}
+
+func isStructOrPtrStruct(fieldType reflect.Type) bool {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Struct
+}
+
+func inSkipped(index []int, skipped [][]int) bool {
+	for _, prefix := range skipped {
+		if len(index) > len(prefix) && slices.Equal(index[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tb *tagBuilder) leaf(valueType reflect.Type, ruleStr string, path string) (ValueRangerChecker, error) {
+	name, arg, hasArg := strings.Cut(ruleStr, "=")
+	if ruleStr == "" { // 没有写规则，按类型只检测类型
+		switch valueType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			name = "int"
+		case reflect.String:
+			name = "string"
+		case reflect.Bool:
+			name = "bool"
+		default:
+			return nil, fmt.Errorf("vr tag %s: type no support: %s", path, valueType.String())
+		}
+	}
+	if valueType.Kind() != reflect.Interface { // interface 的值在检测的时候才检查类型
+		if err := checkRuleKind(name, valueType.Kind()); err != nil {
+			return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+		}
+	}
+	if (name == "enum" || name == "ref") && !hasArg {
+		return nil, fmt.Errorf("vr tag %s: rule %q need an argument, like %s=xxx", path, name, name)
+	}
+	checker, err := tb.vr.ruleChecker(name, arg)
+	if err != nil {
+		return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+	}
+	return checker, nil
+}
+
+// 对上一层容器标记，返回这一层的长度区间，没有写标记的话直接通过，没有长度区间
+func (rule *tagRule) takeContainer(container string, valueType reflect.Type) (string, error) {
+	if rule.containerPos >= len(rule.containers) {
+		return "", nil
+	}
+	if rule.containers[rule.containerPos] != container {
+		return "", fmt.Errorf("container %q not match type %s", rule.containers[rule.containerPos], valueType.String())
+	}
+	rule.containerPos++
+	return rule.lens[rule.containerPos-1], nil
+}
+
+func (tb *tagBuilder) withLen(checker ValueRangerChecker, lenStr, path string) (ValueRangerChecker, error) {
+	if lenStr == "" {
+		return checker, nil
+	}
+	checker, err := tb.vr.withLenErr(checker, lenStr)
+	if err != nil {
+		return nil, fmt.Errorf("vr tag %s: %s", path, err.Error())
+	}
+	return checker, nil
+}
+
+func (rule *tagRule) checkAllUsed(valueType reflect.Type) error {
+	if rule.containerPos < len(rule.containers) {
+		return fmt.Errorf("container %q not match type %s", rule.containers[rule.containerPos], valueType.String())
+	}
+	if rule.keyRule != "" && !rule.keyUsed {
+		return fmt.Errorf("key rule %q used on non map type", rule.keyRule)
+	}
+	return nil
+}
+
+// 各个规则可以用在哪些类型上
+func checkRuleKind(name string, kind reflect.Kind) error {
+	isInt := false
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		isInt = true
+	}
+
+	ok := false
+	switch name {
+	case "int", "enum":
+		ok = isInt
+	case "ref":
+		ok = isInt || kind == reflect.String
+	case "string":
+		ok = kind == reflect.String
+	case "bool":
+		ok = kind == reflect.Bool
+	default:
+		return fmt.Errorf("unknown rule: %q", name)
+	}
+	if !ok {
+		return fmt.Errorf("rule %q can not be used on %s", name, kind.String())
+	}
+	return nil
+}
+
+// 根据规则名和参数创建基础类型的 checker，创建失败的 panic 转为 error
+func (vr *ValueRange) ruleChecker(name, arg string) (checker ValueRangerChecker, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			checker, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	switch name {
+	case "int":
+		return vr.IntValueRangerChecker(arg), nil
+	case "string":
+		return vr.StringValueRangerChecker(arg), nil
+	case "bool":
+		return vr.BoolValueRangerChecker(arg), nil
+	case "enum":
+		return vr.EnumValueRangerChecker(arg), nil
+	case "ref":
+		return vr.RefValueRangerChecker(arg), nil
+	default:
+		return nil, fmt.Errorf("unknown rule: %q", name)
+	}
+}
@@ -0,0 +1,76 @@
+package valuerange
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTagChecker(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+
+	checker := valueRangeChecker.TagValueRangerChecker(heroCfgList)
+	if !checker.Check(heroCfgList) {
+		t.Fatalf("tag checker check heroCfg failed")
+	}
+
+	valueRangeChecker.RegChecker("tagHeroCfg", checker)
+	violations := valueRangeChecker.CheckWithReport("tagHeroCfg", []heroCfg{
+		{Id: 61404, Quality: 7, Skins: []uint64{9}, Attrs: map[uint32]uint32{3: 1}},
+	})
+	wantPaths := []string{"tagHeroCfg[0].Quality", "tagHeroCfg[0].Skins[0]", "tagHeroCfg[0].Attrs[3]"}
+	if len(violations) != len(wantPaths) {
+		t.Fatalf("violations: %v", violations)
+	}
+	for i, want := range wantPaths {
+		if violations[i].Path != want {
+			t.Errorf("violation %d path: %s, want: %s", i, violations[i].Path, want)
+		}
+	}
+}
+
+type badTagUnknownRule struct {
+	Id int `vr:"intt=[0,10]"`
+}
+
+type badTagContainer struct {
+	Skins []uint64 `vr:"map,int"`
+}
+
+type badTagKind struct {
+	Desc string `vr:"enum=heroCfgQuality"`
+}
+
+type badTagRange struct {
+	Id int `vr:"int=[10,0]"`
+}
+
+type badTagNested struct {
+	Rows []badTagRange
+}
+
+func TestTagCheckerMalformed(t *testing.T) {
+	valueRangeChecker := newHeroValueRange(t)
+
+	cases := []struct {
+		sample any
+		want   string
+	}{
+		{badTagUnknownRule{}, `valuerange.badTagUnknownRule.Id: unknown rule: "intt"`},
+		{badTagContainer{}, `valuerange.badTagContainer.Skins: container "map" not match type []uint64`},
+		{badTagKind{}, `valuerange.badTagKind.Desc: rule "enum" can not be used on string`},
+		{badTagRange{}, `valuerange.badTagRange.Id: IntRange max value less than min value`},
+		{badTagNested{}, `valuerange.badTagNested.Rows[].Id: IntRange max value less than min value`},
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				r := recover()
+				if r == nil || !strings.Contains(fmt.Sprint(r), c.want) {
+					t.Errorf("tag checker %T panic: %v, want: %s", c.sample, r, c.want)
+				}
+			}()
+			valueRangeChecker.TagValueRangerChecker(c.sample)
+		}()
+	}
+}
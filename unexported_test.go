@@ -0,0 +1,78 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+// 有未导出字段的配置，规则文件中的字段名可以是小写的
+type monsterCfg struct {
+	Id    uint64
+	level int
+	name  string
+	drops []uint64
+}
+
+func TestUnexportedField(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	valueRangeChecker.LoadOneCfg("monsterCfg", []monsterCfg{
+		{Id: 1, level: 3, name: "slime"},
+		{Id: 2, level: 11, name: "dragon", drops: []uint64{1}},
+	})
+	err := valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"monsterCfg": {"list": {"struct": {"Id": "int", "level": "int=[1,10]", "name": "string", "drops": {"list": "int"}}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+
+	// 基础类型的字段读出来检测，列表拿不到值，检测不通过而不是 panic
+	report := valueRangeChecker.CheckAll()
+	wants := []string{
+		"monsterCfg[0].drops: value field: drops is unexported, type []uint64 can not be read",
+		"monsterCfg[1].level: value 11 (int) not match int[1,10]",
+		"monsterCfg[1].drops: value field: drops is unexported, type []uint64 can not be read",
+	}
+	violations := report.Tables[0].Violations
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if !strings.Contains(violations[i].String(), want) {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	// 编译的时候知道类型，只有基础类型的未导出字段可以编译，其他的直接拒绝
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"monsterLevel": {"struct": {"level": "int=[1,10]"}},
+		"monsterDrops": {"struct": {"drops": {"list": "int"}}}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+	compiled := valueRangeChecker.CompileChecker(valueRangeChecker.checkerStore["monsterLevel"], monsterCfg{})
+	if !compiled.Check(monsterCfg{level: 3}) || compiled.Check(monsterCfg{level: 11}) {
+		t.Errorf("compiled unexported level check failed")
+	}
+	for _, c := range []struct {
+		want string
+		fn   func()
+	}{
+		{"drops is unexported in valuerange.monsterCfg", func() {
+			valueRangeChecker.CompileChecker(valueRangeChecker.checkerStore["monsterDrops"], monsterCfg{})
+		}},
+		{"field key: level is unexported", func() {
+			valueRangeChecker.RefValueRangerChecker("monsterCfg.level")
+		}},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), c.want) {
+					t.Errorf("recover: %v, want: %s", r, c.want)
+				}
+			}()
+			c.fn()
+		}()
+	}
+}
@@ -0,0 +1,87 @@
+package valuerange
+
+import (
+	"strings"
+	"testing"
+)
+
+const dupHeroCSV = `Id,Desc,Quality,Open,Tag.Free,Skins,Attrs
+61401,top,1,true,true,6140101|6140102,1=100
+61402,ace,2,true,false,6140201|6140201,1=150
+61401,mid,3,true,false,6140301,1=200
+`
+
+func TestUniqueRuleFile(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	_, err := valueRangeChecker.LoadOneCSVCfg(heroCfgKey, "hero.csv", strings.NewReader(dupHeroCSV), heroCfg{}, CSVOptions{ListSep: "|", KVSep: "="})
+	if err != nil {
+		t.Fatalf("load hero.csv failed: %v", err)
+	}
+	err = valueRangeChecker.LoadRules("rules.json", []byte(`{"tables": {
+		"heroCfg": {"list": {"struct": {"Id": "int", "Skins": {"list": "int", "unique": true}}}, "unique": ["Id", ["Tag.Free", "Open"]]}
+	}}`))
+	if err != nil {
+		t.Fatalf("load rules failed: %v", err)
+	}
+
+	// 每一个重复的行都记一条，带上第一次出现的行在 csv 中的位置
+	report := valueRangeChecker.CheckAll()
+	wants := []string{
+		"hero.csv:3:F: heroCfg[1].Skins[1]: duplicate value 6140201, same as heroCfg[1].Skins[0] (hero.csv:3:F)",
+		"hero.csv:4:A: heroCfg[2].Id: duplicate Id=61401, same as heroCfg[0].Id (hero.csv:2:A)",
+		"hero.csv:4:E: heroCfg[2].Tag.Free: duplicate Tag.Free=false, Open=true, same as heroCfg[1].Tag.Free (hero.csv:3:E)",
+	}
+	violations := report.Tables[0].Violations
+	if len(violations) != len(wants) {
+		t.Fatalf("check all:\n%s", report.String())
+	}
+	for i, want := range wants {
+		if violations[i].String() != want {
+			t.Errorf("violation %d: %s, want: %s", i, violations[i].String(), want)
+		}
+	}
+
+	cases := []struct {
+		rules string
+		want  string
+	}{
+		{`{"tables": {"a": {"struct": {"Id": "int"}, "unique": true}}}`, `rules.json:1:44: unique can only be used with list, map, is: struct`},
+		{`{"tables": {"a": {"list": "int", "unique": false}}}`, `rules.json:1:44: unique must be true or an array of fields`},
+		{`{"tables": {"a": {"list": "int", "unique": ["Id", []]}}}`, `rules.json:1:51: unique must be a field name or an array of field names`},
+	}
+	for _, c := range cases {
+		err := ValueRangeChecker().LoadRules("rules.json", []byte(c.rules))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("rules: %s\nerr: %v\nwant: %s", c.rules, err, c.want)
+		}
+	}
+}
+
+func TestUniqueMap(t *testing.T) {
+	valueRangeChecker := ValueRangeChecker()
+	newChecker := func() ValueRangerChecker {
+		return valueRangeChecker.WithUnique(valueRangeChecker.MapValueRangerChecker(
+			valueRangeChecker.IntValueRangerChecker(""),
+			valueRangeChecker.StructValueRangerChecker(rewardCfgChecker{
+				Id:   valueRangeChecker.IntValueRangerChecker(""),
+				Type: valueRangeChecker.StringValueRangerChecker(""),
+			}),
+		), "Type", "Value")
+	}
+
+	rewards := map[uint64]*rewardCfg{
+		1: {Id: 1, Type: rewardType_item, Value: 1001},
+		2: {Id: 2, Type: rewardType_item, Value: 1002},
+		3: {Id: 3, Type: rewardType_item, Value: 1001},
+	}
+	checkBothPaths(t, valueRangeChecker, "reward", newChecker, map[uint64]*rewardCfg{}, rewards, "[3].Type: duplicate Type=item, Value=1001, same as {key}[1].Type")
+	delete(rewards, 3) // 没有重复的就通过
+	checkBothPaths(t, valueRangeChecker, "reward", nil, nil, rewards)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("WithUnique on non list checker should panic")
+		}
+	}()
+	valueRangeChecker.WithUnique(valueRangeChecker.IntValueRangerChecker(""), "Id")
+}
@@ -2,6 +2,8 @@ package valuerange
 
 import (
 	"fmt"
+	"io"
+	"reflect"
 
 	basetyperange "github.com/chenjinjie/value-range/internal/base-type-range"
 	expandtyperange "github.com/chenjinjie/value-range/internal/expand-type-range"
@@ -18,6 +20,7 @@ func ValueRangeChecker() *ValueRange {
 		enumStore: expandtyperange.EnumValueStore(),
 
 		checkerStore: make(map[string]ValueRangerChecker),
+		locators:     make(map[string]basetyperange.Locator),
 	}
 }
 
@@ -29,6 +32,14 @@ type ValueRange struct {
 	enumStore *expandtyperange.EnumStore
 
 	checkerStore map[string]ValueRangerChecker
+	locators     map[string]basetyperange.Locator // 从文件加载的配置表，用来把不通过的值定位到文件中的位置
+
+	strictMode StrictMode // 之后创建的 struct checker 默认的严格模式
+}
+
+// 设置检测过程中调试信息的输出，默认是 os.Stdout，传 io.Discard 关掉，所有的 ValueRange 共用
+func SetDebugOutput(w io.Writer) {
+	basetyperange.SetDebugOutput(w)
 }
 
 // 提前加载配置表
@@ -57,7 +68,7 @@ func (vr *ValueRange) RegChecker(key string, checker ValueRangerChecker) {
 func (vr *ValueRange) Check(key string, value any) bool {
 	checker, ok := vr.checkerStore[key]
 	if !ok {
-		fmt.Printf("check rule not exit, key: %s", key)
+		basetyperange.Debugf("check rule not exit, key: %s", key)
 		return false
 	}
 	return checker.Check(value)
@@ -76,7 +87,242 @@ func (vr *ValueRange) BoolValueRangerChecker(rangeStr string) ValueRangerChecker
 }
 
 func (vr *ValueRange) StructValueRangerChecker(checker any) ValueRangerChecker {
-	return basetyperange.StructValueRangerChecker(checker)
+	return basetyperange.StructValueRangerChecker(checker).WithStrict(vr.strictMode)
+}
+
+// 严格模式：值中有没有规则的导出字段时，StrictWarn 记一条提醒，StrictFail 检测不通过
+type StrictMode = basetyperange.StrictMode
+
+const (
+	StrictOff  = basetyperange.StrictOff
+	StrictWarn = basetyperange.StrictWarn
+	StrictFail = basetyperange.StrictFail
+)
+
+// 设置 struct checker 默认的严格模式，只影响之后创建的 checker（包括 tag、规则文件生成的），所以要在创建 checker 之前设置
+func (vr *ValueRange) SetStrictMode(mode StrictMode) {
+	vr.strictMode = mode
+}
+
+// 单独设置一个 struct checker 的严格模式，checker 不是 struct checker 时 panic
+func (vr *ValueRange) WithStrictMode(checker ValueRangerChecker, mode StrictMode) ValueRangerChecker {
+	return asStructRange(checker, "WithStrictMode").WithStrict(mode)
+}
+
+func asStructRange(checker ValueRangerChecker, funcName string) *basetyperange.StructRange {
+	sr, ok := checker.(*basetyperange.StructRange)
+	if !ok {
+		panic(fmt.Sprintf("%s checker is not a struct checker: %s", funcName, checker.ToString()))
+	}
+	return sr
+}
+
+// 分支字段的值 => 这个值时检测整行的 checker，值可以是整数、字符串、bool
+type SwitchCases = map[any]ValueRangerChecker
+
+// 按分支字段的值选择 checker，如 Type 是 item 时检测 Value 是 itemCfg.Id
+// case 的 checker 拿到的是整行，defaultChecker 为 nil 时没有匹配的 case 就不通过
+func (vr *ValueRange) SwitchValueRangerChecker(field string, cases SwitchCases, defaultChecker ValueRangerChecker) ValueRangerChecker {
+	if len(cases) == 0 {
+		panic("SwitchValueRangerChecker has no case, field: " + field)
+	}
+	sw := basetyperange.SwitchValueRangerChecker(field)
+	for value, checker := range cases {
+		sw.AddCase(value, checker)
+	}
+	if defaultChecker != nil {
+		sw.WithDefault(defaultChecker)
+	}
+	return sw
+}
+
+// 给 struct checker 加上分支，分支中检测的字段在严格模式下也算有规则
+// checker 不是 struct checker、switchChecker 不是 SwitchValueRangerChecker 创建的时 panic
+func (vr *ValueRange) WithSwitch(checker ValueRangerChecker, switchChecker ValueRangerChecker) ValueRangerChecker {
+	sw, ok := switchChecker.(*basetyperange.SwitchRange)
+	if !ok {
+		panic(fmt.Sprintf("WithSwitch switchChecker is not a switch checker: %s", switchChecker.ToString()))
+	}
+	return asStructRange(checker, "WithSwitch").AddSwitch(sw)
+}
+
+// 列表、map 中的行不能重复，fields 为空时是元素本身，多个字段时是它们的组合，多次调用加多条规则
+// checker 不是 list、map checker 时 panic
+func (vr *ValueRange) WithUnique(checker ValueRangerChecker, fields ...string) ValueRangerChecker {
+	switch c := checker.(type) {
+	case *basetyperange.ListRange:
+		return c.AddUnique(fields...)
+	case *basetyperange.MapRange:
+		return c.AddUnique(fields...)
+	default:
+		panic(fmt.Sprintf("WithUnique checker is not a list or map checker: %s", checker.ToString()))
+	}
+}
+
+// 列表中的行的顺序
+type Order = basetyperange.Order
+
+const (
+	OrderAsc        = basetyperange.OrderAsc        // 从小到大，可以相等
+	OrderStrictAsc  = basetyperange.OrderStrictAsc  // 严格递增，如升级经验
+	OrderDesc       = basetyperange.OrderDesc       // 从大到小，可以相等，如掉落权重的档位
+	OrderStrictDesc = basetyperange.OrderStrictDesc // 严格递减
+)
+
+// 列表中的行要按 order 排好，field 为空时按元素本身，否则按行中的这个字段，如 Exp
+// 元素不能重复用 WithUnique(checker)；checker 不是 list checker 时 panic
+func (vr *ValueRange) WithOrder(checker ValueRangerChecker, order Order, field string) ValueRangerChecker {
+	lr, ok := checker.(*basetyperange.ListRange)
+	if !ok {
+		panic(fmt.Sprintf("WithOrder checker is not a list checker: %s", checker.ToString()))
+	}
+	return lr.AddOrder(order, field)
+}
+
+// 列表、map 中所有元素汇总起来的值，如掉落权重的和、满足条件的行数
+type Aggregate = basetyperange.Aggregate
+
+// 元素或行中 field 字段的和要在 rangeStr 中，field 为空时是元素本身，如 SumAggregate("Weight", "[10000,10000]")
+func (vr *ValueRange) SumAggregate(field, rangeStr string) *Aggregate {
+	return basetyperange.SumAggregate(field, rangeStr)
+}
+
+// 最小值要在 rangeStr 中，容器为空时不检测
+func (vr *ValueRange) MinAggregate(field, rangeStr string) *Aggregate {
+	return basetyperange.MinAggregate(field, rangeStr)
+}
+
+// 最大值要在 rangeStr 中，容器为空时不检测
+func (vr *ValueRange) MaxAggregate(field, rangeStr string) *Aggregate {
+	return basetyperange.MaxAggregate(field, rangeStr)
+}
+
+// 满足表达式的行数要在 rangeStr 中，where 为空时是所有的行，如 CountAggregate(`Type == "item"`, "[0,3]")
+func (vr *ValueRange) CountAggregate(where, rangeStr string) *Aggregate {
+	return basetyperange.CountAggregate(where, rangeStr)
+}
+
+// 满足 Go 函数的行数要在 rangeStr 中，row 是元素的值（指针已经解开了）
+func (vr *ValueRange) CountFuncAggregate(desc string, fn func(row any) bool, rangeStr string) *Aggregate {
+	return basetyperange.CountFuncAggregate(desc, fn, rangeStr)
+}
+
+// 给 list、map checker 加上汇总的规则，元素都检测完之后再检测，checker 不是 list、map checker 时 panic
+func (vr *ValueRange) WithAggregates(checker ValueRangerChecker, aggregates ...*Aggregate) ValueRangerChecker {
+	switch c := checker.(type) {
+	case *basetyperange.ListRange:
+		for _, a := range aggregates {
+			c.AddAggregate(a)
+		}
+		return c
+	case *basetyperange.MapRange:
+		for _, a := range aggregates {
+			c.AddAggregate(a)
+		}
+		return c
+	default:
+		panic(fmt.Sprintf("WithAggregates checker is not a list or map checker: %s", checker.ToString()))
+	}
+}
+
+// 列表、map 中的值要在 rangeStr 中连续，如等级表 WithSequence(checker, "Level", "[1,-]") 要有 1..N 级不能断
+// field 为空时列表是元素本身、map 是 key；不连续时报出缺了的值和范围外的值；checker 不是 list、map checker 时 panic
+func (vr *ValueRange) WithSequence(checker ValueRangerChecker, field, rangeStr string) ValueRangerChecker {
+	switch c := checker.(type) {
+	case *basetyperange.ListRange:
+		return c.AddSequence(field, rangeStr)
+	case *basetyperange.MapRange:
+		return c.AddSequence(field, rangeStr)
+	default:
+		panic(fmt.Sprintf("WithSequence checker is not a list or map checker: %s", checker.ToString()))
+	}
+}
+
+// map 中这些 key 都要有，如 Attrs 中要有 hp 和 mp，别的 key 不管；checker 不是 map checker 或 keys 为空时 panic
+func (vr *ValueRange) WithRequiredKeys(checker ValueRangerChecker, keys ...any) ValueRangerChecker {
+	return asMapRange(checker, "WithRequiredKeys").AddRequiredKeys(keys...)
+}
+
+// map 的 key 要正好是枚举中的所有值，不能少也不能多，报出缺了的和多出来的 key
+// checker 不是 map checker、枚举没有加载时 panic
+func (vr *ValueRange) WithEnumKeys(checker ValueRangerChecker, enumKey string) ValueRangerChecker {
+	mr := asMapRange(checker, "WithEnumKeys")
+	if !vr.enumStore.EnumRuleExit(enumKey) {
+		panic("WithEnumKeys enumKey not exit: " + enumKey)
+	}
+	return mr.AddKeySet("enum("+enumKey+")", func() ([]any, error) {
+		values, ok := vr.enumStore.EnumValues(enumKey)
+		if !ok {
+			return nil, fmt.Errorf("enum key not exit: %s", enumKey)
+		}
+		keys := make([]any, 0, len(values))
+		for _, value := range values {
+			keys = append(keys, value)
+		}
+		return keys, nil
+	}, true)
+}
+
+func asMapRange(checker ValueRangerChecker, funcName string) *basetyperange.MapRange {
+	mr, ok := checker.(*basetyperange.MapRange)
+	if !ok {
+		panic(fmt.Sprintf("%s checker is not a map checker: %s", funcName, checker.ToString()))
+	}
+	return mr
+}
+
+// 列表、map 的长度范围，和 IntRange 一样的区间写法，如 [1,-] 不能为空、[3,3] 正好 3 个、[0,10] 最多 10 个
+// checker 不是 list、map checker 或区间不合法时 panic
+func (vr *ValueRange) WithLen(checker ValueRangerChecker, rangeStr string) ValueRangerChecker {
+	switch c := checker.(type) {
+	case *basetyperange.ListRange:
+		return c.WithLen(rangeStr)
+	case *basetyperange.MapRange:
+		return c.WithLen(rangeStr)
+	default:
+		panic(fmt.Sprintf("WithLen checker is not a list or map checker: %s", checker.ToString()))
+	}
+}
+
+// 同 WithLen，区间不合法时返回错误，给 tag、规则文件用
+func (vr *ValueRange) withLenErr(checker ValueRangerChecker, rangeStr string) (result ValueRangerChecker, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("len %v", r)
+		}
+	}()
+	return vr.WithLen(checker, rangeStr), nil
+}
+
+// 结构体级别的约束，拿到整行去检测，如 MinLevel <= MaxLevel
+type Constraint = basetyperange.Constraint
+
+// 表达式写的约束，语法见 internal/field-expr，如 if Open then Quality >= 3，表达式不合法时 panic
+func (vr *ValueRange) ExprConstraint(expr string) *Constraint {
+	return basetyperange.ExprConstraint(expr)
+}
+
+// Go 函数写的约束，row 是结构体的值（指针已经解开了），fields 是涉及的字段，不通过时记在这些字段上
+// fn 只判断条件，不通过的信息中带上 fields 的值，不用返回原因
+func (vr *ValueRange) FuncConstraint(desc string, fn func(row any) bool, fields ...string) *Constraint {
+	return basetyperange.FuncConstraint(desc, fn, fields...)
+}
+
+// 给 struct checker 加上约束，字段都检测完之后再检测，checker 不是 struct checker 时 panic
+func (vr *ValueRange) WithConstraints(checker ValueRangerChecker, constraints ...*Constraint) ValueRangerChecker {
+	sr := asStructRange(checker, "WithConstraints")
+	for _, c := range constraints {
+		sr.AddConstraint(c)
+	}
+	return sr
+}
+
+// 用 struct checker 检测 map[string]any 时，少 key、多 key 的处理方式
+type MapKeyOptions = basetyperange.MapKeyOptions
+
+// 同 StructValueRangerChecker，检测的值是 map[string]any 这样的 map 时，按 opts 处理少 key、多 key
+func (vr *ValueRange) StructValueRangerCheckerWithOptions(checker any, opts MapKeyOptions) ValueRangerChecker {
+	return basetyperange.StructValueRangerChecker(checker).WithStrict(vr.strictMode).WithMapKeyOptions(opts)
 }
 
 func (vr *ValueRange) ListValueRangerChecker(fieldChecker ValueRangerChecker) ValueRangerChecker {
@@ -94,3 +340,61 @@ func (vr *ValueRange) RefValueRangerChecker(rangeStr string) ValueRangerChecker
 func (vr *ValueRange) EnumValueRangerChecker(enumKey string) ValueRangerChecker {
 	return expandtyperange.EnumValueRangerChecker(vr.enumStore, enumKey)
 }
+
+// 可以不填的值：nil 指针、nil slice、nil map 通过，填了的话用 checker 检测，指针会解一层引用
+func (vr *ValueRange) OptionalValueRangerChecker(checker ValueRangerChecker) ValueRangerChecker {
+	return basetyperange.OptionalValueRangerChecker(checker)
+}
+
+// 必填的值：nil 指针、nil slice、nil map 不通过，填了的话用 checker 检测，指针会解一层引用
+func (vr *ValueRange) RequiredValueRangerChecker(checker ValueRangerChecker) ValueRangerChecker {
+	return basetyperange.RequiredValueRangerChecker(checker)
+}
+
+// 每一个 checker 都要通过，不通过的都会记下来
+func (vr *ValueRange) AndValueRangerChecker(checkers ...ValueRangerChecker) ValueRangerChecker {
+	if len(checkers) == 0 {
+		panic("AndValueRangerChecker has no checker")
+	}
+	ar := basetyperange.AndValueRangerChecker()
+	for _, checker := range checkers {
+		ar.Add(checker)
+	}
+	return ar
+}
+
+// 有一个 checker 通过就行，如 enum 或者 0：OrValueRangerChecker(enumChecker, IntValueRangerChecker("[0,0]"))
+// 都不通过时记一条，带上每一个分支不通过的原因
+func (vr *ValueRange) OrValueRangerChecker(checkers ...ValueRangerChecker) ValueRangerChecker {
+	if len(checkers) == 0 {
+		panic("OrValueRangerChecker has no checker")
+	}
+	or := basetyperange.OrValueRangerChecker()
+	for _, checker := range checkers {
+		or.Add(checker)
+	}
+	return or
+}
+
+// checker 通过时不通过
+func (vr *ValueRange) NotValueRangerChecker(checker ValueRangerChecker) ValueRangerChecker {
+	return basetyperange.NotValueRangerChecker(checker)
+}
+
+// 什么值都通过，如只检测 map 的 key 时 value 用它
+func (vr *ValueRange) AnyValueRangerChecker() ValueRangerChecker {
+	return basetyperange.AnyValueRangerChecker()
+}
+
+// 把 Go 函数当作 checker 用，fn 返回 nil 通过，返回的 error 是不通过的原因；desc 用于报告
+// 和 FuncConstraint、CountFuncAggregate、泛型的 NewFuncChecker 的区别见 internal/base-type-range/combinator.go
+func (vr *ValueRange) FuncValueRangerChecker(desc string, fn func(value any) error) ValueRangerChecker {
+	return basetyperange.FuncValueRangerChecker(desc, fn)
+}
+
+// 把 checker 编译到 sample 的类型上
+// 编译时会缓存结构体字段的下标，并为各个类型选好专门的检测路径，检测同一类型的大量数据时更快
+// 检测的值和 sample 类型不一致的时候，会退回到原来的 checker
+func (vr *ValueRange) CompileChecker(checker ValueRangerChecker, sample any) ValueRangerChecker {
+	return basetyperange.CompileValueRangerChecker(checker, reflect.TypeOf(sample))
+}
@@ -7,14 +7,15 @@ type heroTagCfg struct {
 	Free bool // 免费使用英雄
 }
 
+// vr tag 是给 TagValueRangerChecker 用的，和 heroCfgChecker 描述的是一样的规则
 type heroCfg struct {
 	Id      uint64            // 配置id
 	Desc    string            // 描述
-	Quality int               // 品质
+	Quality int               `vr:"enum=heroCfgQuality"` // 品质
 	Open    bool              // 是否开放可以使用
 	Tag     heroTagCfg        // 英雄标签配置
-	Skins   []uint64          // 可以使用的皮肤配置id列表
-	Attrs   map[uint32]uint32 // 英雄属性列表 key: 属性id value: 属性值
+	Skins   []uint64          `vr:"list,ref=heroSkinCfg.Id"`            // 可以使用的皮肤配置id列表
+	Attrs   map[uint32]uint32 `vr:"map,key:enum=heroCfgAttr,int=(0,-)"` // 英雄属性列表 key: 属性id value: 属性值
 }
 
 // 假设有这么一张 hero.csv 配置表的数据